@@ -0,0 +1,108 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/mapprotocol/monitor/internal/chain"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/mempool"
+	"github.com/mapprotocol/monitor/pkg/price"
+	"github.com/mapprotocol/monitor/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// decimals is the number of satoshi digits in one BTC; waterLines are
+// written in BTC and compared in satoshi.
+const decimals = config.BtcDecimals
+
+type Monitor struct {
+	*chain.Common
+	client    mempool.BTCAPIClient
+	netParams *chaincfg.Params
+}
+
+func NewMonitor(cs *chain.Common, client mempool.BTCAPIClient, netParams *chaincfg.Params) *Monitor {
+	return &Monitor{
+		Common:    cs,
+		client:    client,
+		netParams: netParams,
+	}
+}
+
+func (m *Monitor) Sync() error {
+	m.Log.Debug("Starting listener...")
+	m.Wg.Add(1)
+	go func() {
+		defer m.Wg.Done()
+		if err := m.sync(); err != nil {
+			m.Log.Error("Polling Account balance failed", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+func (m *Monitor) sync() error {
+	for {
+		select {
+		case <-m.Stop:
+			return errors.New("polling terminated")
+		default:
+			snap := m.Snapshot()
+			waterLine, err := price.WaterLine(snap.WaterLine, snap.Symbol, decimals)
+			if err != nil {
+				m.Log.Error("Chain waterLine invalid, skip from balance check", "waterLine", snap.WaterLine, "err", err)
+			}
+
+			for _, ele := range snap.From {
+				if ele == "" || waterLine == nil {
+					continue
+				}
+				m.checkBalance(ele, "unknown", waterLine)
+			}
+
+			for _, ele := range snap.Users {
+				wl, err := price.WaterLine(ele.WaterLine, snap.Symbol, decimals)
+				if err != nil {
+					m.Log.Error("User waterLine invalid, skip balance check", "group", ele.Group, "waterLine", ele.WaterLine, "err", err)
+					continue
+				}
+				for _, addr := range strings.Split(ele.From, ",") {
+					m.checkBalance(addr, ele.Group, wl)
+				}
+			}
+
+			time.Sleep(config.BalanceRetryInterval)
+		}
+	}
+}
+
+func (m *Monitor) checkBalance(addr, group string, waterLine *big.Int) {
+	address, err := btcutil.DecodeAddress(strings.TrimSpace(addr), m.netParams)
+	if err != nil {
+		m.Log.Error("CheckBalance decode address failed", "account", addr, "network", m.netParams.Name, "err", err)
+		return
+	}
+
+	balance, err := m.client.GetBalance(address)
+	if err != nil {
+		m.Log.Error("CheckBalance GetBalance failed", "account", addr, "err", err)
+		return
+	}
+
+	bal := amount.FromInt64(balance, decimals)
+	wl := amount.New(waterLine, decimals)
+	m.Log.Info("Get balance result", "account", addr, "balance", bal, "wl", wl)
+	if bal.LessThan(wl) {
+		util.Alarm(context.Background(),
+			fmt.Sprintf("Balance Less than %s BTC,chains=%s group=%s addr=%s balance=%s%s",
+				wl, m.Cfg.Name, group, addr, bal, price.Describe(m.Snapshot().Symbol, bal)))
+	}
+}
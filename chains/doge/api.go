@@ -0,0 +1,167 @@
+package doge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/mapprotocol/monitor/pkg/mempool"
+)
+
+const (
+	IndexerEsplora   = "esplora"
+	IndexerBlockbook = "blockbook"
+)
+
+// API is the indexer backend the doge monitor reads from. It mirrors
+// mempool.BTCAPIClient, so the Esplora client in pkg/mempool satisfies it as
+// is and a Blockbook instance or a local HTTP stub can stand in for it.
+type API interface {
+	ListUnspent(address btcutil.Address) ([]*mempool.UnspentOutput, error)
+	GetBalance(address btcutil.Address) (int64, error)
+	TipHeight() (int64, error)
+}
+
+var (
+	_ API = (*mempool.MempoolClient)(nil)
+	_ API = (*BlockbookClient)(nil)
+)
+
+// MainNetParams and TestNetParams only carry what address decoding needs.
+var (
+	MainNetParams = chaincfg.Params{
+		Name:             "dogecoin",
+		Net:              wire.BitcoinNet(0xc0c0c0c0),
+		PubKeyHashAddrID: 0x1e,
+		ScriptHashAddrID: 0x16,
+		PrivateKeyID:     0x9e,
+	}
+	TestNetParams = chaincfg.Params{
+		Name:             "dogecoin-testnet",
+		Net:              wire.BitcoinNet(0xdcb7c1fc),
+		PubKeyHashAddrID: 0x71,
+		ScriptHashAddrID: 0xc4,
+		PrivateKeyID:     0xf1,
+	}
+)
+
+// ParseNetwork maps a configured network name to its address params. An
+// empty name selects mainnet.
+func ParseNetwork(network string) (*chaincfg.Params, error) {
+	switch strings.ToLower(strings.TrimSpace(network)) {
+	case "", "mainnet":
+		return &MainNetParams, nil
+	case "testnet":
+		return &TestNetParams, nil
+	default:
+		return nil, fmt.Errorf("unsupported dogecoin network %q", network)
+	}
+}
+
+// NewAPI returns the backend selected by the indexer option: Esplora (the
+// default) or Blockbook, both rooted at baseURL.
+func NewAPI(indexer, baseURL string, netParams *chaincfg.Params) (API, error) {
+	switch strings.ToLower(strings.TrimSpace(indexer)) {
+	case "", IndexerEsplora:
+		client, err := mempool.New(mempool.Options{BaseURL: baseURL, Network: netParams})
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case IndexerBlockbook:
+		return NewBlockbookClient(baseURL), nil
+	default:
+		return nil, fmt.Errorf("unsupported doge indexer %q", indexer)
+	}
+}
+
+// BlockbookClient reads from the Blockbook v2 REST API. Requests time out
+// after mempool.DefaultTimeout, like those of the Esplora client.
+type BlockbookClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewBlockbookClient(baseURL string) *BlockbookClient {
+	return &BlockbookClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: mempool.DefaultTimeout},
+	}
+}
+
+type blockbookAddress struct {
+	Address            string `json:"address"`
+	Balance            string `json:"balance"`
+	UnconfirmedBalance string `json:"unconfirmedBalance"`
+}
+
+type blockbookUtxo struct {
+	Txid          string `json:"txid"`
+	Vout          int    `json:"vout"`
+	Value         string `json:"value"`
+	Confirmations int64  `json:"confirmations"`
+}
+
+type blockbookStatus struct {
+	Blockbook struct {
+		BestHeight int64 `json:"bestHeight"`
+	} `json:"blockbook"`
+}
+
+func (c *BlockbookClient) GetBalance(address btcutil.Address) (int64, error) {
+	var ret blockbookAddress
+	if err := c.get(fmt.Sprintf("/api/v2/address/%s?details=basic", address.EncodeAddress()), &ret); err != nil {
+		return 0, err
+	}
+	balance, err := strconv.ParseInt(ret.Balance, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse blockbook balance %q: %w", ret.Balance, err)
+	}
+	return balance, nil
+}
+
+func (c *BlockbookClient) ListUnspent(address btcutil.Address) ([]*mempool.UnspentOutput, error) {
+	var utxos []blockbookUtxo
+	if err := c.get(fmt.Sprintf("/api/v2/utxo/%s", address.EncodeAddress()), &utxos); err != nil {
+		return nil, err
+	}
+
+	unspentOutputs := make([]*mempool.UnspentOutput, 0, len(utxos))
+	for _, utxo := range utxos {
+		txHash, err := chainhash.NewHashFromStr(utxo.Txid)
+		if err != nil {
+			return nil, err
+		}
+		value, err := strconv.ParseInt(utxo.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse blockbook utxo value %q: %w", utxo.Value, err)
+		}
+		unspentOutputs = append(unspentOutputs, &mempool.UnspentOutput{
+			Outpoint: wire.NewOutPoint(txHash, uint32(utxo.Vout)),
+			Output:   wire.NewTxOut(value, address.ScriptAddress()),
+		})
+	}
+	return unspentOutputs, nil
+}
+
+func (c *BlockbookClient) TipHeight() (int64, error) {
+	var ret blockbookStatus
+	if err := c.get("/api/v2", &ret); err != nil {
+		return 0, err
+	}
+	return ret.Blockbook.BestHeight, nil
+}
+
+func (c *BlockbookClient) get(subPath string, ret interface{}) error {
+	body, err := mempool.RequestWith(c.httpClient, http.MethodGet, c.baseURL, subPath, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, ret)
+}
@@ -0,0 +1,129 @@
+package doge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/mapprotocol/monitor/internal/chain"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/mempool"
+)
+
+const testAddr = "DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L"
+
+func newStub(t *testing.T, routes map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, ok := routes[req.URL.Path]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = rw.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDecodeDogeAddress(t *testing.T) {
+	address, err := btcutil.DecodeAddress(testAddr, &MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.EncodeAddress() != testAddr {
+		t.Fatalf("EncodeAddress = %s, want %s", address.EncodeAddress(), testAddr)
+	}
+}
+
+func TestNewAPI_Esplora(t *testing.T) {
+	server := newStub(t, map[string]string{
+		"/address/" + testAddr:           `{"chain_stats":{"funded_txo_sum":500000000,"spent_txo_sum":100000000}}`,
+		"/address/" + testAddr + "/utxo": `[{"txid":"b752d80e97196582fd02303f76b4b886c222070323fb7ccd425f6c89f5445f6c","vout":1,"value":400000000}]`,
+		"/blocks/tip/height":             "5123456",
+	})
+	client, err := NewAPI("", server.URL, &MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAPI(t, client, 400000000, 1, 5123456)
+}
+
+func TestNewAPI_Blockbook(t *testing.T) {
+	server := newStub(t, map[string]string{
+		"/api/v2/address/" + testAddr: `{"address":"` + testAddr + `","balance":"400000000","unconfirmedBalance":"0"}`,
+		"/api/v2/utxo/" + testAddr:    `[{"txid":"b752d80e97196582fd02303f76b4b886c222070323fb7ccd425f6c89f5445f6c","vout":0,"value":"250000000","confirmations":3},{"txid":"b752d80e97196582fd02303f76b4b886c222070323fb7ccd425f6c89f5445f6c","vout":1,"value":"150000000","confirmations":0}]`,
+		"/api/v2":                     `{"blockbook":{"bestHeight":5123457},"backend":{"blocks":5123457}}`,
+	})
+	client, err := NewAPI(IndexerBlockbook, server.URL+"/", &MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAPI(t, client, 400000000, 2, 5123457)
+	if timeout := client.(*BlockbookClient).httpClient.Timeout; timeout != mempool.DefaultTimeout {
+		t.Fatalf("blockbook timeout = %s, want %s", timeout, mempool.DefaultTimeout)
+	}
+}
+
+func TestNewAPI_EsploraRequiresURL(t *testing.T) {
+	if _, err := NewAPI(IndexerEsplora, "", &MainNetParams); err == nil {
+		t.Fatal("expected error for esplora without base url")
+	}
+}
+
+func TestNewAPI_Unknown(t *testing.T) {
+	if _, err := NewAPI("insight", "http://localhost", &MainNetParams); err == nil {
+		t.Fatal("expected error for unknown indexer")
+	}
+}
+
+func assertAPI(t *testing.T, client API, balance int64, utxos int, height int64) {
+	t.Helper()
+	address, err := btcutil.DecodeAddress(testAddr, &MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotBalance, err := client.GetBalance(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotBalance != balance {
+		t.Fatalf("GetBalance = %d, want %d", gotBalance, balance)
+	}
+	gotUtxos, err := client.ListUnspent(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotUtxos) != utxos {
+		t.Fatalf("ListUnspent returned %d utxos, want %d", len(gotUtxos), utxos)
+	}
+	gotHeight, err := client.TipHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotHeight != height {
+		t.Fatalf("TipHeight = %d, want %d", gotHeight, height)
+	}
+}
+
+// TestRecordHeight_StallAfterCheckCount: the indexer is reported stalled
+// only once its tip has stayed put for checkHgtCount polls, and a new tip
+// resets the counter.
+func TestRecordHeight_StallAfterCheckCount(t *testing.T) {
+	cs := chain.NewCommonSync(nil, &config.OptConfig{Name: "doge"}, nil, nil, nil)
+	m := NewMonitor(cs, nil, &MainNetParams)
+
+	if m.recordHeight(100, 2) {
+		t.Fatal("first height must not be reported stalled")
+	}
+	if m.recordHeight(100, 2) {
+		t.Fatal("stalled after one unchanged poll, want two")
+	}
+	if !m.recordHeight(100, 2) {
+		t.Fatal("expected stall after two unchanged polls")
+	}
+	if m.recordHeight(101, 2) {
+		t.Fatal("new tip must reset the stall counter")
+	}
+}
@@ -0,0 +1,76 @@
+package doge
+
+import (
+	"github.com/ChainSafe/log15"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/mapprotocol/monitor/internal/chain"
+	"github.com/mapprotocol/monitor/internal/config"
+)
+
+type Chain struct {
+	cfg    *config.ChainConfig // The config of the chain
+	stop   chan<- int
+	listen chain.Listener
+}
+
+// New builds a dogecoin chain monitor. Endpoint is the base URL of the
+// indexer selected by opts.indexer (esplora by default, or blockbook) and
+// Network selects the address encoding: mainnet (default) or testnet.
+func New(chainCfg *config.ChainConfig, logger log15.Logger, sysErr chan<- error, tks *config.Token,
+	genni *config.Api, users []config.From) (*Chain, error) {
+	cfg, err := config.ParseOptConfig(chainCfg, tks, genni, users)
+	if err != nil {
+		return nil, err
+	}
+
+	netParams, err := ParseNetwork(chainCfg.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan int)
+	logger.Info("Connecting to doge chain...", "url", cfg.Endpoint, "network", netParams.Name,
+		"indexer", chainCfg.Opts[config.Indexer])
+	client, err := NewAPI(chainCfg.Opts[config.Indexer], cfg.Endpoint, netParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var listen chain.Listener
+	cs := chain.NewCommonSync(nil, cfg, logger, stop, sysErr)
+	listen = NewMonitor(cs, client, netParams)
+
+	return &Chain{
+		cfg:    chainCfg,
+		stop:   stop,
+		listen: listen,
+	}, nil
+}
+
+func (c *Chain) Name() string {
+	return c.cfg.Name
+}
+
+func (c *Chain) Start() error {
+	err := c.listen.Sync()
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Successfully started chain")
+	return nil
+}
+
+func (c *Chain) Stop() {
+	close(c.stop)
+	c.listen.Wait()
+}
+
+func (c *Chain) Id() config.ChainId {
+	return c.cfg.Id
+}
+
+// UpdateCfg forwards a config mutation to the listener (used by hot reload).
+func (c *Chain) UpdateCfg(fn func(*config.OptConfig)) {
+	c.listen.UpdateCfg(fn)
+}
@@ -0,0 +1,158 @@
+package doge
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/mapprotocol/monitor/internal/chain"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/price"
+	"github.com/mapprotocol/monitor/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// decimals is the number of koinu digits in one DOGE; waterLines are written
+// in DOGE and compared in koinu.
+const decimals = config.DogeDecimals
+
+type Monitor struct {
+	*chain.Common
+	client       API
+	netParams    *chaincfg.Params
+	heightCount  int64
+	syncedHeight int64
+}
+
+func NewMonitor(cs *chain.Common, client API, netParams *chaincfg.Params) *Monitor {
+	return &Monitor{
+		Common:    cs,
+		client:    client,
+		netParams: netParams,
+	}
+}
+
+func (m *Monitor) Sync() error {
+	m.Log.Debug("Starting listener...")
+	m.Wg.Add(1)
+	go func() {
+		defer m.Wg.Done()
+		if err := m.sync(); err != nil {
+			m.Log.Error("Polling Account balance failed", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+func (m *Monitor) sync() error {
+	for {
+		select {
+		case <-m.Stop:
+			return errors.New("polling terminated")
+		default:
+			snap := m.Snapshot()
+			waterLine, err := price.WaterLine(snap.WaterLine, snap.Symbol, decimals)
+			if err != nil {
+				m.Log.Error("Chain waterLine invalid, skip from balance check", "waterLine", snap.WaterLine, "err", err)
+			}
+
+			for _, ele := range snap.From {
+				if ele == "" || waterLine == nil {
+					continue
+				}
+				m.checkAddress(ele, "unknown", waterLine, snap.UtxoWaterLine)
+			}
+
+			for _, ele := range snap.Users {
+				wl, err := price.WaterLine(ele.WaterLine, snap.Symbol, decimals)
+				if err != nil {
+					m.Log.Error("User waterLine invalid, skip balance check", "group", ele.Group, "waterLine", ele.WaterLine, "err", err)
+					continue
+				}
+				for _, addr := range strings.Split(ele.From, ",") {
+					m.checkAddress(addr, ele.Group, wl, snap.UtxoWaterLine)
+				}
+			}
+
+			m.checkIndexer(snap.CheckHgtCount)
+
+			time.Sleep(config.BalanceRetryInterval)
+		}
+	}
+}
+
+// checkAddress compares the balance of addr against waterLine and, when
+// utxoWaterLine is positive, its UTXO count against that.
+func (m *Monitor) checkAddress(addr, group string, waterLine *big.Int, utxoWaterLine int64) {
+	address, err := btcutil.DecodeAddress(strings.TrimSpace(addr), m.netParams)
+	if err != nil {
+		m.Log.Error("CheckBalance decode address failed", "account", addr, "network", m.netParams.Name, "err", err)
+		return
+	}
+
+	balance, err := m.client.GetBalance(address)
+	if err != nil {
+		m.Log.Error("CheckBalance GetBalance failed", "account", addr, "err", err)
+		return
+	}
+	bal := amount.FromInt64(balance, decimals)
+	wl := amount.New(waterLine, decimals)
+	m.Log.Info("Get balance result", "account", addr, "balance", bal, "wl", wl)
+	if bal.LessThan(wl) {
+		util.Alarm(context.Background(),
+			fmt.Sprintf("Balance Less than %s DOGE,chains=%s group=%s addr=%s balance=%s%s",
+				wl, m.Cfg.Name, group, addr, bal, price.Describe(m.Snapshot().Symbol, bal)))
+	}
+
+	if utxoWaterLine <= 0 {
+		return
+	}
+	utxos, err := m.client.ListUnspent(address)
+	if err != nil {
+		m.Log.Error("CheckUtxo ListUnspent failed", "account", addr, "err", err)
+		return
+	}
+	m.Log.Info("Get utxo result", "account", addr, "count", len(utxos), "wl", utxoWaterLine)
+	if int64(len(utxos)) < utxoWaterLine {
+		util.Alarm(context.Background(),
+			fmt.Sprintf("UTXO count Less than %d,chains=%s group=%s addr=%s count=%d",
+				utxoWaterLine, m.Cfg.Name, group, addr, len(utxos)))
+	}
+}
+
+// checkIndexer alarms when the indexer tip height has not moved for
+// checkHgtCount consecutive polls, DefaultCheckHgtCount when it is not set.
+func (m *Monitor) checkIndexer(checkHgtCount int64) {
+	if checkHgtCount <= 0 {
+		checkHgtCount = config.DefaultCheckHgtCount
+	}
+	height, err := m.client.TipHeight()
+	if err != nil {
+		m.Log.Error("CheckIndexer TipHeight failed", "err", err)
+		return
+	}
+	m.Log.Info("Check indexer height", "height", height, "record", m.syncedHeight, "heightCount", m.heightCount)
+	if m.recordHeight(height, checkHgtCount) {
+		util.Alarm(context.Background(),
+			fmt.Sprintf("Indexer Height No change for %d polls chains=%s, height=%d",
+				checkHgtCount, m.Cfg.Name, height))
+	}
+}
+
+// recordHeight stores height and reports whether it has been unchanged for
+// at least checkHgtCount polls.
+func (m *Monitor) recordHeight(height, checkHgtCount int64) bool {
+	if height != m.syncedHeight {
+		m.syncedHeight = height
+		m.heightCount = 0
+		return false
+	}
+	m.heightCount++
+	return m.heightCount >= checkHgtCount
+}
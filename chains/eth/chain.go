@@ -11,10 +11,11 @@ import (
 )
 
 type Chain struct {
-	cfg    *config.ChainConfig // The config of the chain
-	conn   chain.Connection    // The chains connection
-	stop   chan<- int
-	listen chain.Listener // The listener of this chain
+	cfg     *config.ChainConfig // The config of the chain
+	conn    chain.Connection    // The chains connection
+	stop    chan<- int
+	listen  chain.Listener // The listener of this chain
+	scanner *Scanner       // nil when the chain has no scan config
 }
 
 func InitializeChain(chainCfg *config.ChainConfig, logger log15.Logger, sysErr chan<- error, tks *config.Token,
@@ -35,13 +36,24 @@ func InitializeChain(chainCfg *config.ChainConfig, logger log15.Logger, sysErr c
 	// simplified a little bit
 	var listen chain.Listener
 	cs := chain.NewCommonSync(conn, cfg, logger, stop, sysErr)
-	listen = monitor.New(cs)
+	m := monitor.New(cs)
+	listen = m
+
+	var scanner *Scanner
+	if chainCfg.Scan != nil {
+		scanner, err = NewScanner(chainCfg, conn.Client(), logger, stop)
+		if err != nil {
+			return nil, err
+		}
+		scanner.RegisterRange(m)
+	}
 
 	return &Chain{
-		cfg:    chainCfg,
-		conn:   conn,
-		stop:   stop,
-		listen: listen,
+		cfg:     chainCfg,
+		conn:    conn,
+		stop:    stop,
+		listen:  listen,
+		scanner: scanner,
 	}, nil
 }
 
@@ -50,6 +62,9 @@ func (c *Chain) Start() error {
 	if err != nil {
 		return err
 	}
+	if c.scanner != nil {
+		c.scanner.Start()
+	}
 
 	log.Debug("Successfully started chain")
 	return nil
@@ -68,6 +83,9 @@ func (c *Chain) Name() string {
 func (c *Chain) Stop() {
 	close(c.stop)
 	c.listen.Wait()
+	if c.scanner != nil {
+		c.scanner.Wait()
+	}
 	if c.conn != nil {
 		c.conn.Close()
 	}
@@ -0,0 +1,205 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ChainSafe/log15"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/blockstore"
+	"github.com/pkg/errors"
+)
+
+// EventHandler is an event-driven check fed by the Scanner. HandleLogs gets
+// the matching logs of blocks [from, to] in block order; the range is only
+// checkpointed once every handler returned nil, so a failed range is handed
+// over again on the next poll, to every handler. Handlers must therefore be
+// idempotent: a range one of them already handled may come again.
+type EventHandler interface {
+	HandleLogs(from, to uint64, logs []types.Log) error
+}
+
+// RangeHandler is an event-driven check that reads what it needs of blocks
+// [from, to] itself. Like an EventHandler, the range is only checkpointed
+// once HandleRange returned nil, and it must cope with a range handed over
+// again after another handler failed.
+type RangeHandler interface {
+	HandleRange(ctx context.Context, from, to uint64) error
+}
+
+// logReader is the part of ethclient.Client the Scanner reads blocks with.
+type logReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// Scanner walks the blocks of an EVM chain from its last checkpoint, filters
+// the logs of the configured contracts and topics for the registered
+// EventHandlers and hands every range to the RangeHandlers. The last block
+// handled is stored in the blockstore, so after a restart no block is
+// skipped. A range is checkpointed once for all handlers: when one of them
+// or the checkpoint fails, the whole range is handed over again, including
+// to the handlers that already handled it.
+type Scanner struct {
+	client        logReader
+	store         *blockstore.Blockstore
+	log           log15.Logger
+	stop          <-chan int
+	wg            sync.WaitGroup
+	handlers      []EventHandler
+	ranges        []RangeHandler
+	query         ethereum.FilterQuery
+	startBlock    uint64
+	confirmations uint64
+	batch         uint64
+	interval      time.Duration
+	next          uint64 // first block not handled yet, 0 until resolved
+}
+
+// NewScanner builds the scanner of chainCfg.Scan, checkpointing under
+// chainCfg.BlockstorePath.
+func NewScanner(chainCfg *config.ChainConfig, client logReader, log log15.Logger, stop <-chan int) (*Scanner, error) {
+	scan := chainCfg.Scan
+	store, err := blockstore.NewBlockstore(chainCfg.BlockstorePath, chainCfg.Id, chainCfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scanner{
+		client:        client,
+		store:         store,
+		log:           log,
+		stop:          stop,
+		startBlock:    scan.StartBlock,
+		confirmations: scan.Confirmations,
+		batch:         scan.Batch,
+		interval:      time.Duration(scan.Interval) * time.Second,
+	}
+	if s.confirmations == 0 {
+		s.confirmations = config.DefaultScanConfirmations
+	}
+	if s.batch == 0 {
+		s.batch = config.DefaultScanBatch
+	}
+	if s.interval <= 0 {
+		s.interval = config.DefaultScanInterval
+	}
+	for _, c := range scan.Contracts {
+		s.query.Addresses = append(s.query.Addresses, common.HexToAddress(c))
+	}
+	if topics := config.TopicHashes(scan.Topics); len(topics) > 0 {
+		s.query.Topics = [][]common.Hash{topics}
+	}
+	return s, nil
+}
+
+// Register adds h to the handlers of the logs of the scan contracts.
+func (s *Scanner) Register(h EventHandler) {
+	s.handlers = append(s.handlers, h)
+}
+
+// RegisterRange adds h to the handlers of every scanned range.
+func (s *Scanner) RegisterRange(h RangeHandler) {
+	s.ranges = append(s.ranges, h)
+}
+
+// Start runs the scan loop until stop is closed.
+func (s *Scanner) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			more, err := s.poll(context.Background())
+			if err != nil {
+				s.log.Error("Scan blocks failed", "next", s.next, "err", err)
+			}
+			wait := s.interval
+			if more && err == nil {
+				wait = 0
+			}
+			select {
+			case <-s.stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+}
+
+// Wait blocks until the scan loop has exited.
+func (s *Scanner) Wait() {
+	s.wg.Wait()
+}
+
+// poll handles the next batch of confirmed blocks and reports whether more
+// confirmed blocks are waiting.
+func (s *Scanner) poll(ctx context.Context) (bool, error) {
+	head, err := s.client.BlockNumber(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "block number")
+	}
+	if head < s.confirmations {
+		return false, nil
+	}
+	safe := head - s.confirmations
+	if s.next == 0 {
+		if s.next, err = s.resume(safe); err != nil {
+			return false, err
+		}
+	}
+	if s.next > safe {
+		return false, nil
+	}
+	to := s.next + s.batch - 1
+	if to > safe {
+		to = safe
+	}
+
+	var logs []types.Log
+	if len(s.handlers) > 0 {
+		query := s.query
+		query.FromBlock = new(big.Int).SetUint64(s.next)
+		query.ToBlock = new(big.Int).SetUint64(to)
+		if logs, err = s.client.FilterLogs(ctx, query); err != nil {
+			return false, errors.Wrapf(err, "filter logs %d-%d", s.next, to)
+		}
+	}
+	for _, h := range s.handlers {
+		if err := h.HandleLogs(s.next, to, logs); err != nil {
+			return false, errors.Wrapf(err, "handle logs %d-%d", s.next, to)
+		}
+	}
+	for _, h := range s.ranges {
+		if err := h.HandleRange(ctx, s.next, to); err != nil {
+			return false, errors.Wrapf(err, "handle blocks %d-%d", s.next, to)
+		}
+	}
+	if err := s.store.StoreBlock(new(big.Int).SetUint64(to)); err != nil {
+		return false, errors.Wrapf(err, "checkpoint %d", to)
+	}
+	s.log.Info("Scanned blocks", "from", s.next, "to", to, "logs", len(logs), "head", head)
+	s.next = to + 1
+	return to < safe, nil
+}
+
+// resume returns the first block to scan: the one after the checkpoint,
+// else the configured start block, else safe.
+func (s *Scanner) resume(safe uint64) (uint64, error) {
+	stored, err := s.store.TryLoadLatestBlock()
+	if err != nil {
+		return 0, errors.Wrap(err, "load checkpoint")
+	}
+	switch {
+	case stored.Sign() > 0:
+		s.log.Info("Scan resumes from checkpoint", "block", stored)
+		return stored.Uint64() + 1, nil
+	case s.startBlock > 0:
+		return s.startBlock, nil
+	default:
+		return safe, nil
+	}
+}
@@ -0,0 +1,148 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/log15"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mapprotocol/monitor/internal/config"
+)
+
+var scanContract = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+// fakeLogs serves one log of scanContract in every block up to head.
+type fakeLogs struct {
+	head    uint64
+	queries []ethereum.FilterQuery
+}
+
+func (f *fakeLogs) BlockNumber(context.Context) (uint64, error) { return f.head, nil }
+
+func (f *fakeLogs) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	f.queries = append(f.queries, q)
+	var logs []types.Log
+	for b := q.FromBlock.Uint64(); b <= q.ToBlock.Uint64(); b++ {
+		logs = append(logs, types.Log{Address: scanContract, BlockNumber: b})
+	}
+	return logs, nil
+}
+
+type recordHandler struct {
+	blocks []uint64
+	fail   bool
+}
+
+func (h *recordHandler) HandleLogs(_, _ uint64, logs []types.Log) error {
+	if h.fail {
+		return errors.New("handler down")
+	}
+	for _, l := range logs {
+		h.blocks = append(h.blocks, l.BlockNumber)
+	}
+	return nil
+}
+
+func newTestScanner(t *testing.T, dir string, client logReader, scan config.Scan) *Scanner {
+	t.Helper()
+	cfg := &config.ChainConfig{Name: "eth", Id: 1, BlockstorePath: dir, Scan: &scan}
+	s, err := NewScanner(cfg, client, log15.New(), make(chan int))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestScanner_ResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	chain := &fakeLogs{head: 20}
+	scan := config.Scan{Contracts: []string{scanContract.Hex()}, Topics: []string{"Transfer(address,address,uint256)"},
+		StartBlock: 10, Confirmations: 2, Batch: 5}
+
+	s := newTestScanner(t, dir, chain, scan)
+	h := &recordHandler{}
+	s.Register(h)
+	if more, err := s.poll(context.Background()); err != nil || !more {
+		t.Fatalf("first poll: more=%v err=%v", more, err)
+	}
+	if more, err := s.poll(context.Background()); err != nil || more {
+		t.Fatalf("second poll: more=%v err=%v", more, err)
+	}
+	if len(h.blocks) != 9 || h.blocks[0] != 10 || h.blocks[8] != 18 {
+		t.Fatalf("handled blocks %v, want 10..18", h.blocks)
+	}
+	if q := chain.queries[0]; len(q.Addresses) != 1 || len(q.Topics) != 1 || len(q.Topics[0]) != 1 {
+		t.Fatalf("query = %+v", q)
+	}
+
+	// a restarted scanner picks up after block 18 and never sees it again
+	chain.head = 25
+	restarted := newTestScanner(t, dir, chain, scan)
+	again := &recordHandler{}
+	restarted.Register(again)
+	if _, err := restarted.poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(again.blocks) != 5 || again.blocks[0] != 19 || again.blocks[4] != 23 {
+		t.Fatalf("restarted scanner handled %v, want 19..23", again.blocks)
+	}
+}
+
+func TestScanner_FailedRangeIsRetried(t *testing.T) {
+	chain := &fakeLogs{head: 10}
+	s := newTestScanner(t, t.TempDir(), chain, config.Scan{Contracts: []string{scanContract.Hex()}, StartBlock: 1})
+	h := &recordHandler{fail: true}
+	s.Register(h)
+	if _, err := s.poll(context.Background()); err == nil {
+		t.Fatal("expected the handler error")
+	}
+	h.fail = false
+	if _, err := s.poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.blocks) != 4 || h.blocks[0] != 1 {
+		t.Fatalf("handled %v, want the failed range 1..4 again", h.blocks)
+	}
+}
+
+func TestScanner_StartsAtHeadWithoutCheckpoint(t *testing.T) {
+	chain := &fakeLogs{head: 100}
+	s := newTestScanner(t, t.TempDir(), chain, config.Scan{Contracts: []string{scanContract.Hex()}})
+	h := &recordHandler{}
+	s.Register(h)
+	if _, err := s.poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.blocks) != 1 || h.blocks[0] != 100-config.DefaultScanConfirmations {
+		t.Fatalf("handled %v, want only the confirmed head", h.blocks)
+	}
+}
+
+type recordRange struct{ ranges [][2]uint64 }
+
+func (h *recordRange) HandleRange(_ context.Context, from, to uint64) error {
+	h.ranges = append(h.ranges, [2]uint64{from, to})
+	return nil
+}
+
+func TestScanner_RangeHandlers(t *testing.T) {
+	chain := &fakeLogs{head: 20}
+	s := newTestScanner(t, t.TempDir(), chain, config.Scan{StartBlock: 5, Batch: 4})
+	h := &recordRange{}
+	s.RegisterRange(h)
+	for more := true; more; {
+		var err error
+		if more, err = s.poll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(h.ranges) != 3 || h.ranges[0] != [2]uint64{5, 8} || h.ranges[2] != [2]uint64{13, 14} {
+		t.Fatalf("ranges = %v", h.ranges)
+	}
+	if len(chain.queries) != 0 {
+		t.Fatalf("logs filtered without an event handler: %d queries", len(chain.queries))
+	}
+}
@@ -6,12 +6,17 @@ import (
 	"fmt"
 	"github.com/mapprotocol/monitor/internal/config"
 	"github.com/mapprotocol/monitor/internal/mapprotocol"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/price"
 	"github.com/mapprotocol/monitor/pkg/util"
 	"github.com/mapprotocol/near-api-go/pkg/client/block"
 	"math/big"
 	"time"
 )
 
+// decimals is the number of yoctoNEAR digits in one NEAR.
+const decimals = config.NearDecimals
+
 type Monitor struct {
 	*CommonListen
 	balance, syncedHeight      *big.Int
@@ -58,14 +63,13 @@ func (m *Monitor) sync() error {
 			return errors.New("polling terminated")
 		default:
 			snap := m.Snapshot()
-			waterLine, ok := config.ParseNativeWaterLine(snap.WaterLine, 24)
-			if !ok {
-				m.sysErr <- errors.New("near waterLine Not Number")
-				return nil
-			}
-
-			for _, from := range snap.From {
-				m.checkBalance(from, waterLine, snap.Name)
+			waterLine, err := price.WaterLine(snap.WaterLine, snap.Symbol, decimals)
+			if err != nil {
+				m.log.Error("Near waterLine invalid, skip balance check", "waterLine", snap.WaterLine, "err", err)
+			} else {
+				for _, from := range snap.From {
+					m.checkBalance(from, waterLine, snap.Name)
+				}
 			}
 
 			height, err := mapprotocol.Get2MapHeight(snap.Id)
@@ -100,17 +104,20 @@ func (m *Monitor) checkBalance(addr string, waterLine *big.Int, chainName string
 
 	m.log.Info("Get balance result", "account", addr, "balance", resp.Amount.String())
 
-	v, ok := new(big.Int).SetString(resp.Amount.String(), 10)
-	if ok && v.Cmp(m.balance) != 0 {
+	balance, err := amount.FromRawString(resp.Amount.String(), decimals)
+	if err != nil {
+		m.log.Error("Parse user balance failed", "from", addr, "err", err)
+		return
+	}
+	if v := balance.Raw(); v.Cmp(m.balance) != 0 {
 		m.balance = v
 		m.timestamp = time.Now().Unix()
 	}
 
-	if v.Cmp(waterLine) == -1 {
-		conversion := new(big.Int).Div(v, config.WeiOfNear)
-		wl := new(big.Int).Div(new(big.Int).Set(waterLine), config.WeiOfNear)
+	wl := amount.New(waterLine, decimals)
+	if balance.LessThan(wl) {
 		util.Alarm(context.Background(),
-			fmt.Sprintf("Balance Less than %d Near chain=%s addr=%s near=%d", wl.Int64(),
-				chainName, addr, conversion.Int64()))
+			fmt.Sprintf("Balance Less than %s Near chain=%s addr=%s near=%s%s", wl,
+				chainName, addr, balance, price.Describe(m.Snapshot().Symbol, balance)))
 	}
 }
@@ -7,17 +7,17 @@ import (
 	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/mapprotocol/monitor/internal/chain"
 	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/price"
 	"github.com/mapprotocol/monitor/pkg/util"
 	"github.com/pkg/errors"
 	"math/big"
-	"strconv"
 	"strings"
 	"time"
 )
 
-var (
-	wei = big.NewFloat(1000000)
-)
+// decimals is the number of lamport digits in one SOL.
+const decimals = config.SolDecimals
 
 type Monitor struct {
 	*chain.Common
@@ -25,7 +25,7 @@ type Monitor struct {
 	heightCount           int64
 	balance, syncedHeight *big.Int
 	timestamp             int64
-	balMapping            map[string]float64
+	balMapping            map[string]amount.Amount
 }
 
 func NewMonitor(cs *chain.Common, conn *rpc.Client) *Monitor {
@@ -34,7 +34,7 @@ func NewMonitor(cs *chain.Common, conn *rpc.Client) *Monitor {
 		conn:         conn,
 		balance:      new(big.Int),
 		syncedHeight: new(big.Int),
-		balMapping:   make(map[string]float64),
+		balMapping:   make(map[string]amount.Amount),
 	}
 }
 
@@ -58,29 +58,26 @@ func (m *Monitor) sync() error {
 			return errors.New("polling terminated")
 		default:
 			snap := m.Snapshot()
-			waterLine, err := strconv.ParseFloat(snap.WaterLine, 64)
+			waterLine, err := price.WaterLine(snap.WaterLine, snap.Symbol, decimals)
 			if err != nil {
-				m.Log.Error("Error parsing water line", "WaterLine", snap.WaterLine, "err", err)
-				m.SysErr <- fmt.Errorf("%s waterLine Not Number", snap.Name)
-				return err
+				m.Log.Error("Chain waterLine invalid, skip from balance check", "waterLine", snap.WaterLine, "err", err)
 			}
 
 			for _, ele := range snap.From {
-				if ele == "" {
+				if ele == "" || waterLine == nil {
 					continue
 				}
 				m.checkBalance(ele, "unknown", waterLine)
 			}
 
 			for _, ele := range snap.Users {
-				wl, ok := new(big.Float).SetString(ele.WaterLine)
-				wlFloat, _ := wl.Float64()
-				if !ok {
-					m.SysErr <- fmt.Errorf("%s waterLine Not Number", snap.Name)
-					return nil
+				wl, err := price.WaterLine(ele.WaterLine, snap.Symbol, decimals)
+				if err != nil {
+					m.Log.Error("User waterLine invalid, skip balance check", "group", ele.Group, "waterLine", ele.WaterLine, "err", err)
+					continue
 				}
 				for _, addr := range strings.Split(ele.From, ",") {
-					m.checkBalance(addr, ele.Group, wlFloat)
+					m.checkBalance(addr, ele.Group, wl)
 				}
 			}
 
@@ -93,26 +90,22 @@ func (m *Monitor) sync() error {
 	}
 }
 
-func (m *Monitor) checkBalance(addr, group string, waterLine float64) {
+func (m *Monitor) checkBalance(addr, group string, waterLine *big.Int) {
 	balance, err := m.conn.GetBalance(context.TODO(), solana.MustPublicKeyFromBase58(addr), rpc.CommitmentFinalized)
 	if err != nil {
 		m.Log.Error("m.conn.GetBalance failed", "err", err)
 		return
 	}
 
-	bal, _ := new(big.Float).Quo(big.NewFloat(0).SetUint64(balance.Value),
-		big.NewFloat(1000000000)).Float64()
-	//if !ok.String() {
-	//	m.Log.Error("Error parsing water line", "value", balance.Value, "err", err)
-	//}
-
+	bal := amount.FromUint64(balance.Value, decimals)
+	wl := amount.New(waterLine, decimals)
 	m.Log.Info("Get balance result", "account", addr, "balance", bal)
 
-	if bal < waterLine {
+	if bal.LessThan(wl) {
 		// alarm
 		util.Alarm(context.Background(),
-			fmt.Sprintf("Balance Less than %0.4f Balance,chains=%s group=%s addr=%s balance=%0.4f",
-				waterLine, m.Cfg.Name, group, addr, bal))
+			fmt.Sprintf("Balance Less than %s Balance,chains=%s group=%s addr=%s balance=%s%s",
+				wl, m.Cfg.Name, group, addr, bal, price.Describe(m.Snapshot().Symbol, bal)))
 	}
 }
 
@@ -129,17 +122,21 @@ func (m *Monitor) checkToken(contract string, tokens []config.EthToken) {
 			continue
 		}
 
-		m.Log.Info("Get Token result", "token", tk.Name, "addr", tk.Addr, "overage", out.Value.UiAmountString)
-		overage, ok := big.NewFloat(0).SetString(out.Value.UiAmountString)
-		if !ok {
-			m.Log.Error("Get token balance, overage is invalid", "account", tk.Addr, "overage", out.Value.UiAmountString)
+		overage, err := amount.FromRawString(out.Value.Amount, int32(out.Value.Decimals))
+		if err != nil {
+			m.Log.Error("Get token balance, overage is invalid", "account", tk.Addr, "overage", out.Value.Amount, "err", err)
+			continue
+		}
+		m.Log.Info("Get Token result", "token", tk.Name, "addr", tk.Addr, "overage", overage)
+		wl, err := amount.FromFloat(tk.WaterLine, overage.Decimals())
+		if err != nil {
+			m.Log.Error("Get token balance, waterLine invalid", "token", tk.Name, "err", err)
 			continue
 		}
-		overFl, _ := overage.Float64()
-		if overFl < tk.WaterLine {
+		if overage.LessThan(wl) {
 			// alarm
 			util.Alarm(context.Background(),
-				fmt.Sprintf("Token Less than %0.4f waterLine ,chains=%s token=%s overage=%0.4f", tk.WaterLine, m.Cfg.Name, tk.Name, overage))
+				fmt.Sprintf("Token Less than %s waterLine ,chains=%s token=%s overage=%s", wl, m.Cfg.Name, tk.Name, overage))
 		}
 	}
 }
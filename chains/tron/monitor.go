@@ -8,16 +8,19 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/lbtsm/gotron-sdk/pkg/address"
 	"github.com/mapprotocol/monitor/internal/chain"
 	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/price"
+	"github.com/mapprotocol/monitor/pkg/tokenmeta"
 	"github.com/mapprotocol/monitor/pkg/util"
 	"github.com/pkg/errors"
 )
 
-var (
-	wei = big.NewFloat(1000000)
-)
+// decimals is the number of sun digits in one TRX.
+const decimals = config.TronDecimals
 
 type Monitor struct {
 	*chain.Common
@@ -25,17 +28,32 @@ type Monitor struct {
 	heightCount                      int64
 	balance, syncedHeight *big.Int
 	timestamp                        int64
-	balMapping                       map[string]float64
+	balMapping                       map[string]amount.Amount
+	tokens                           *tokenmeta.Cache
 }
 
 func NewMonitor(cs *chain.Common, tronConn *Connection) *Monitor {
-	return &Monitor{
+	m := &Monitor{
 		Common:       cs,
 		conn:         tronConn,
 		balance:      new(big.Int),
 		syncedHeight: new(big.Int),
-		balMapping:   make(map[string]float64),
+		balMapping:   make(map[string]amount.Amount),
+	}
+	m.tokens = tokenmeta.NewCache(m.callToken, cs.Log)
+	return m
+}
+
+// callToken makes a constant call of data against token, a hex address.
+func (m *Monitor) callToken(token string, data []byte) ([]byte, error) {
+	result, err := m.conn.cli.TRC20Call("", hexToTronBase58(token), hexutil.Encode(data), true, 0)
+	if err != nil {
+		return nil, err
 	}
+	if len(result.GetConstantResult()) == 0 {
+		return nil, errors.Errorf("no constant result from %s", token)
+	}
+	return result.GetConstantResult()[0], nil
 }
 
 func (m *Monitor) Sync() error {
@@ -58,24 +76,23 @@ func (m *Monitor) sync() error {
 			return errors.New("polling terminated")
 		default:
 			snap := m.Snapshot()
-			waterLine, ok := new(big.Int).SetString(snap.WaterLine, 10)
-			if !ok {
-				m.SysErr <- fmt.Errorf("%s waterLine Not Number", snap.Name)
-				return nil
+			waterLine, err := price.WaterLine(snap.WaterLine, snap.Symbol, decimals)
+			if err != nil {
+				m.Log.Error("Chain waterLine invalid, skip from balance check", "waterLine", snap.WaterLine, "err", err)
 			}
 
 			for _, ele := range snap.From {
-				if ele == "" {
+				if ele == "" || waterLine == nil {
 					continue
 				}
 				m.checkBalance(ele, "unknown", waterLine, true)
 			}
 
 			for _, ele := range snap.Users {
-				wl, ok := new(big.Int).SetString(ele.WaterLine, 10)
-				if !ok {
-					m.SysErr <- fmt.Errorf("%s waterLine Not Number", snap.Name)
-					return nil
+				wl, err := price.WaterLine(ele.WaterLine, snap.Symbol, decimals)
+				if err != nil {
+					m.Log.Error("User waterLine invalid, skip balance check", "group", ele.Group, "waterLine", ele.WaterLine, "err", err)
+					continue
 				}
 				for _, addr := range strings.Split(ele.From, ",") {
 					m.checkBalance(addr, ele.Group, wl, false)
@@ -99,33 +116,18 @@ func (m *Monitor) checkBalance(form, group string, waterLine *big.Int, report bo
 		m.Log.Error("CheckBalance GetAccount failed", "account", form, "err", err)
 		return
 	}
-	balance, _ := big.NewFloat(0).Quo(big.NewFloat(0).SetInt64(account.Balance), wei).Float64()
+	balance := amount.FromInt64(account.Balance, decimals)
+	wl := amount.New(waterLine, decimals)
 	m.Log.Info("CheckBalance, account detail", "account", form, "balance", balance)
-	if balance < float64(waterLine.Int64()) {
+	if balance.LessThan(wl) {
 		util.Alarm(context.Background(),
-			fmt.Sprintf("Balance Less than %d Balance,chains=%s group=%s addr=%s balance=%0.4f",
-				waterLine.Int64(), m.Cfg.Name, group, form, balance))
+			fmt.Sprintf("Balance Less than %s Balance,chains=%s group=%s addr=%s balance=%s%s",
+				wl, m.Cfg.Name, group, form, balance, price.Describe(m.Snapshot().Symbol, balance)))
 		return
 	}
 
 }
 
-func (m *Monitor) checkEnergy(energies []config.Energy) {
-	for _, ele := range energies {
-		resource, err := m.conn.cli.GetAccountResource(ele.Address)
-		if err != nil {
-			m.Log.Error("CheckEnergy GetAccountResource failed", "account", ele.Address, "err", err)
-			continue
-		}
-		m.Log.Info("CheckEnergy, account detail", "account", ele.Address, "energy", resource.EnergyLimit, "used", resource.EnergyUsed)
-		if (resource.EnergyLimit - resource.EnergyUsed) < ele.Waterline {
-			util.Alarm(context.Background(),
-				fmt.Sprintf("Energy Less than %d,chains=%s addr=%s energy=%d", ele.Waterline, m.Cfg.Name, ele.Address, resource.EnergyLimit-resource.EnergyUsed))
-			continue
-		}
-	}
-}
-
 // hexToTronBase58 converts an ethereum hex address (0x...) to tron base58 format.
 func hexToTronBase58(hexAddr string) string {
 	tronHex := "41" + strings.TrimPrefix(strings.ToLower(hexAddr), "0x")
@@ -142,17 +144,17 @@ func (m *Monitor) checkToken(contract common.Address, tokens []config.EthToken)
 			continue
 		}
 
-		wei := tk.Wei
-		if wei == 0 {
-			wei = 18
+		meta := m.tokens.Resolve(tk)
+		overage := amount.New(ret, meta.Decimals)
+		wl, err := amount.FromFloat(tk.WaterLine, meta.Decimals)
+		if err != nil {
+			m.Log.Error("CheckToken waterLine invalid", "token", tk.Name, "err", err)
+			continue
 		}
-
-		retF, _ := ret.Float64()
-		overage, _ := big.NewFloat(0).Quo(big.NewFloat(retF), util.ToWeiFloat(int64(1), int(wei))).Float64()
-		m.Log.Info("Get Token result", "token", tk.Name, "overage", overage, "addr", tk.Addr)
-		if overage < tk.WaterLine {
+		m.Log.Info("Get Token result", "token", tk.Name, "symbol", meta.Symbol, "overage", overage, "addr", tk.Addr)
+		if overage.LessThan(wl) {
 			util.Alarm(context.Background(),
-				fmt.Sprintf("Token Less than %0.4f waterLine ,chains=%s token=%s addr=%s overage=%0.4f", tk.WaterLine, m.Cfg.Name, tk.Name, contract, overage))
+				fmt.Sprintf("Token Less than %s waterLine ,chains=%s token=%s addr=%s overage=%s", wl, m.Cfg.Name, tk.Name, contract, overage))
 		}
 	}
 }
@@ -0,0 +1,167 @@
+package tron
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lbtsm/gotron-sdk/pkg/address"
+	"github.com/lbtsm/gotron-sdk/pkg/client"
+	tronCommon "github.com/lbtsm/gotron-sdk/pkg/common"
+	"github.com/lbtsm/gotron-sdk/pkg/proto/api"
+	"github.com/lbtsm/gotron-sdk/pkg/proto/core"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/util"
+)
+
+const resourceTimeout = 10 * time.Second
+
+// resources is what checkEnergy reads about one account.
+type resources struct {
+	Energy    int64 // EnergyLimit - EnergyUsed
+	Bandwidth int64 // staked plus free bandwidth left
+	Delegated []stake
+	Frozen    []stake
+}
+
+// stake is TRX delegated to the account, or frozen by it, for one resource.
+// Expire is when the owner may take it back, zero when it has no lock.
+type stake struct {
+	From     string // delegating account, empty for the account's own stake
+	Resource string
+	Amount   int64 // sun
+	Expire   time.Time
+}
+
+func (m *Monitor) checkEnergy(energies []config.Energy) {
+	now := time.Now()
+	for _, ele := range energies {
+		res, err := m.readResources(ele.Address)
+		if err != nil {
+			m.Log.Error("CheckEnergy GetAccountResource failed", "account", ele.Address, "err", err)
+			continue
+		}
+		// the stake checks are extra: failing them must not hide the waterlines
+		if res.Frozen, err = m.frozenBy(ele.Address); err != nil {
+			m.Log.Error("CheckEnergy GetAccount failed, frozen stake not checked", "account", ele.Address, "err", err)
+		}
+		if res.Delegated, err = m.delegatedTo(ele.Address); err != nil {
+			m.Log.Error("CheckEnergy delegated resources failed, delegations not checked", "account", ele.Address, "err", err)
+		}
+		m.Log.Info("CheckEnergy, account detail", "account", ele.Address, "energy", res.Energy, "bandwidth", res.Bandwidth,
+			"delegated", len(res.Delegated), "frozen", len(res.Frozen))
+		for _, msg := range evaluateResources(m.Cfg.Name, ele, res, now) {
+			util.Alarm(context.Background(), msg)
+		}
+	}
+}
+
+// readResources reads the energy and bandwidth left on addr.
+func (m *Monitor) readResources(addr string) (resources, error) {
+	var res resources
+	resource, err := m.conn.cli.GetAccountResource(addr)
+	if err != nil {
+		return res, err
+	}
+	res.Energy = resource.EnergyLimit - resource.EnergyUsed
+	res.Bandwidth = (resource.NetLimit - resource.NetUsed) + (resource.FreeNetLimit - resource.FreeNetUsed)
+	return res, nil
+}
+
+// frozenBy lists the legacy stake 1.0 freezes of addr and logs its stake
+// 2.0 amounts being unfrozen.
+func (m *Monitor) frozenBy(addr string) ([]stake, error) {
+	account, err := m.conn.cli.GetAccount(addr)
+	if err != nil {
+		return nil, err
+	}
+	var stakes []stake
+	for _, frozen := range account.GetFrozen() {
+		stakes = append(stakes, stake{Resource: "bandwidth", Amount: frozen.FrozenBalance, Expire: fromMillis(frozen.ExpireTime)})
+	}
+	if frozen := account.GetAccountResource().GetFrozenBalanceForEnergy(); frozen.GetFrozenBalance() > 0 {
+		stakes = append(stakes, stake{Resource: "energy", Amount: frozen.FrozenBalance, Expire: fromMillis(frozen.ExpireTime)})
+	}
+	for _, unfreeze := range account.GetUnfrozenV2() {
+		m.Log.Warn("CheckEnergy, stake is unfreezing", "account", addr, "resource", resourceName(unfreeze.Type),
+			"amount", unfreeze.UnfreezeAmount, "withdrawable", fromMillis(unfreeze.UnfreezeExpireTime))
+	}
+	return stakes, nil
+}
+
+// delegatedTo lists the stake 2.0 resources other accounts delegate to addr.
+func (m *Monitor) delegatedTo(addr string) ([]stake, error) {
+	to, err := tronCommon.DecodeCheck(addr)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resourceTimeout)
+	defer cancel()
+	index, err := m.conn.cli.Client.GetDelegatedResourceAccountIndexV2(ctx, client.GetMessageBytes(to))
+	if err != nil {
+		return nil, err
+	}
+	var stakes []stake
+	for _, from := range index.GetFromAccounts() {
+		list, err := m.conn.cli.Client.GetDelegatedResourceV2(ctx, &api.DelegatedResourceMessage{FromAddress: from, ToAddress: to})
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range list.GetDelegatedResource() {
+			owner := address.Address(d.From).String()
+			if d.FrozenBalanceForEnergy > 0 {
+				stakes = append(stakes, stake{From: owner, Resource: "energy", Amount: d.FrozenBalanceForEnergy, Expire: fromMillis(d.ExpireTimeForEnergy)})
+			}
+			if d.FrozenBalanceForBandwidth > 0 {
+				stakes = append(stakes, stake{From: owner, Resource: "bandwidth", Amount: d.FrozenBalanceForBandwidth, Expire: fromMillis(d.ExpireTimeForBandwidth)})
+			}
+		}
+	}
+	return stakes, nil
+}
+
+// evaluateResources returns the alarms res raises under e on chain name.
+// Delegations and freezes alarm once their lock ends within the expireWarn
+// window; once it has ended the owner may reclaim them at any time, which is
+// the normal state of an unlocked delegation and does not alarm.
+func evaluateResources(name string, e config.Energy, res resources, now time.Time) []string {
+	var msgs []string
+	if res.Energy < e.Waterline {
+		msgs = append(msgs, fmt.Sprintf("Energy Less than %d,chains=%s addr=%s energy=%d", e.Waterline, name, e.Address, res.Energy))
+	}
+	if e.BandwidthWaterline > 0 && res.Bandwidth < e.BandwidthWaterline {
+		msgs = append(msgs, fmt.Sprintf("Bandwidth Less than %d,chains=%s addr=%s bandwidth=%d", e.BandwidthWaterline, name, e.Address, res.Bandwidth))
+	}
+	warn := e.ExpireWarnAfter()
+	for _, s := range res.Delegated {
+		if expiresWithin(s.Expire, now, warn) {
+			msgs = append(msgs, fmt.Sprintf("Delegated %s expiring,chains=%s addr=%s from=%s amount=%d expire=%s",
+				s.Resource, name, e.Address, s.From, s.Amount, s.Expire.UTC().Format(time.RFC3339)))
+		}
+	}
+	for _, s := range res.Frozen {
+		if expiresWithin(s.Expire, now, warn) {
+			msgs = append(msgs, fmt.Sprintf("Frozen %s expiring,chains=%s addr=%s amount=%d expire=%s",
+				s.Resource, name, e.Address, s.Amount, s.Expire.UTC().Format(time.RFC3339)))
+		}
+	}
+	return msgs
+}
+
+func expiresWithin(expire, now time.Time, warn time.Duration) bool {
+	return !expire.IsZero() && expire.After(now) && expire.Sub(now) <= warn
+}
+
+func fromMillis(ms int64) time.Time {
+	if ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+func resourceName(code core.ResourceCode) string {
+	if code == core.ResourceCode_ENERGY {
+		return "energy"
+	}
+	return "bandwidth"
+}
@@ -0,0 +1,43 @@
+package tron
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mapprotocol/monitor/internal/config"
+)
+
+func TestEvaluateResources(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	e := config.Energy{Address: "TRelayer", Waterline: 100000, BandwidthWaterline: 1000}
+	res := resources{
+		Energy:    200000,
+		Bandwidth: 5000,
+		Delegated: []stake{
+			{From: "TOwner1", Resource: "energy", Amount: 1e9, Expire: now.Add(2 * time.Hour)},  // within a day
+			{From: "TOwner2", Resource: "energy", Amount: 1e9, Expire: now.Add(72 * time.Hour)}, // later
+			{From: "TOwner3", Resource: "energy", Amount: 1e9},                                  // no lock
+			{From: "TOwner4", Resource: "bandwidth", Amount: 1e9, Expire: now.Add(-time.Hour)},  // lock ended
+		},
+		Frozen: []stake{{Resource: "bandwidth", Amount: 1e9, Expire: now.Add(time.Hour)}},
+	}
+
+	msgs := evaluateResources("tron", e, res, now)
+	if len(msgs) != 2 || !strings.Contains(msgs[0], "from=TOwner1") || !strings.HasPrefix(msgs[1], "Frozen bandwidth expiring") {
+		t.Fatalf("msgs = %v", msgs)
+	}
+
+	res.Energy, res.Bandwidth = 10, 10
+	e.ExpireWarn = 60 // one minute: nothing expires that soon
+	msgs = evaluateResources("tron", e, res, now)
+	if len(msgs) != 2 || !strings.HasPrefix(msgs[0], "Energy Less than 100000,chains=tron addr=TRelayer") ||
+		!strings.HasPrefix(msgs[1], "Bandwidth Less than 1000") {
+		t.Fatalf("msgs = %v", msgs)
+	}
+
+	e.BandwidthWaterline = 0
+	if msgs = evaluateResources("tron", e, res, now); len(msgs) != 1 {
+		t.Fatalf("bandwidth check should be off, got %v", msgs)
+	}
+}
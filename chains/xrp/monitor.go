@@ -11,13 +11,14 @@ import (
 	"github.com/lbtsm/xrpl-go/model/transactions/types"
 	"github.com/mapprotocol/monitor/internal/chain"
 	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/price"
 	"github.com/mapprotocol/monitor/pkg/util"
 	"github.com/pkg/errors"
 )
 
-var (
-	wei = big.NewFloat(1000000)
-)
+// decimals is the number of drop digits in one XRP.
+const decimals = config.XrpDecimals
 
 type Monitor struct {
 	*chain.Common
@@ -25,7 +26,7 @@ type Monitor struct {
 	heightCount           int64
 	balance, syncedHeight *big.Int
 	timestamp             int64
-	balMapping            map[string]float64
+	balMapping            map[string]amount.Amount
 }
 
 func NewMonitor(cs *chain.Common, tronConn *Connection) *Monitor {
@@ -34,7 +35,7 @@ func NewMonitor(cs *chain.Common, tronConn *Connection) *Monitor {
 		conn:         tronConn,
 		balance:      new(big.Int),
 		syncedHeight: new(big.Int),
-		balMapping:   make(map[string]float64),
+		balMapping:   make(map[string]amount.Amount),
 	}
 }
 
@@ -58,24 +59,23 @@ func (m *Monitor) sync() error {
 			return errors.New("polling terminated")
 		default:
 			snap := m.Snapshot()
-			waterLine, ok := new(big.Int).SetString(snap.WaterLine, 10)
-			if !ok {
-				m.SysErr <- fmt.Errorf("%s waterLine Not Number", snap.Name)
-				return nil
+			waterLine, err := price.WaterLine(snap.WaterLine, snap.Symbol, decimals)
+			if err != nil {
+				m.Log.Error("Chain waterLine invalid, skip from balance check", "waterLine", snap.WaterLine, "err", err)
 			}
 
 			for _, ele := range snap.From {
-				if ele == "" {
+				if ele == "" || waterLine == nil {
 					continue
 				}
 				m.checkBalance(ele, "unknown", waterLine, true)
 			}
 
 			for _, ele := range snap.Users {
-				wl, ok := new(big.Int).SetString(ele.WaterLine, 10)
-				if !ok {
-					m.SysErr <- fmt.Errorf("%s waterLine Not Number", snap.Name)
-					return nil
+				wl, err := price.WaterLine(ele.WaterLine, snap.Symbol, decimals)
+				if err != nil {
+					m.Log.Error("User waterLine invalid, skip balance check", "group", ele.Group, "waterLine", ele.WaterLine, "err", err)
+					continue
 				}
 				for _, addr := range strings.Split(ele.From, ",") {
 					m.checkBalance(addr, ele.Group, wl, false)
@@ -95,13 +95,13 @@ func (m *Monitor) checkBalance(form, group string, waterLine *big.Int, report bo
 		return
 	}
 
-	balance, _ := big.NewFloat(0).Quo(big.NewFloat(0).SetInt64(int64(account.AccountData.Balance)),
-		wei).Float64()
-	m.Log.Info("CheckBalance, account detail", "account", form, "balance", balance, "waterLine", waterLine)
-	if balance < float64(waterLine.Int64()) {
+	balance := amount.FromUint64(uint64(account.AccountData.Balance), decimals)
+	wl := amount.New(waterLine, decimals)
+	m.Log.Info("CheckBalance, account detail", "account", form, "balance", balance, "waterLine", wl)
+	if balance.LessThan(wl) {
 		util.Alarm(context.Background(),
-			fmt.Sprintf("Balance Less than %d Balance,chains=%s group=%s addr=%s balance=%0.4f",
-				waterLine.Int64(), m.Cfg.Name, group, form, balance))
+			fmt.Sprintf("Balance Less than %s Balance,chains=%s group=%s addr=%s balance=%s%s",
+				wl, m.Cfg.Name, group, form, balance, price.Describe(m.Snapshot().Symbol, balance)))
 	}
 
 }
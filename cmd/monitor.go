@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"reflect"
 	"strconv"
 	"strings"
 
+	"github.com/mapprotocol/monitor/chains/btc"
+	"github.com/mapprotocol/monitor/chains/doge"
 	"github.com/mapprotocol/monitor/chains/near"
 	"github.com/mapprotocol/monitor/chains/sol"
 	"github.com/mapprotocol/monitor/chains/tron"
@@ -17,6 +20,8 @@ import (
 	"github.com/mapprotocol/monitor/internal/config"
 	"github.com/mapprotocol/monitor/internal/core"
 	"github.com/mapprotocol/monitor/internal/mapprotocol"
+	"github.com/mapprotocol/monitor/pkg/price"
+	"github.com/mapprotocol/monitor/pkg/status"
 	"github.com/urfave/cli/v2"
 )
 
@@ -33,6 +38,7 @@ var monitorCommand = cli.Command{
 type chainBuilder struct {
 	mapChainID   string
 	keystorePath string
+	blockstore   string
 	tk           *config.Token
 	genni        *config.Api
 	sysErr       chan<- error
@@ -51,16 +57,19 @@ func (b *chainBuilder) buildChainConfig(rc config.RawChainConfig) (*config.Chain
 	rc.Opts[config.MapChainID] = b.mapChainID
 	return &config.ChainConfig{
 		Name:             rc.Name,
+		Type:             rc.Type,
 		Id:               config.ChainId(chainId),
 		Endpoint:         rc.Endpoint,
 		From:             rc.From,
 		Network:          rc.Network,
 		KeystorePath:     b.keystorePath,
+		BlockstorePath:   b.blockstore,
 		NearKeystorePath: rc.KeystorePath,
 		Opts:             rc.Opts,
 		ContractToken:    rc.ContractToken,
 		Energies:         rc.Energies,
 		Tss:              rc.Tss,
+		Scan:             rc.Scan,
 	}, nil
 }
 
@@ -80,6 +89,10 @@ func (b *chainBuilder) buildChain(rc config.RawChainConfig) (chain.Chain, error)
 		return sol.New(chainCfg, logger, b.sysErr, b.tk, b.genni, rc.Users)
 	case config.Xrp:
 		return xrp.New(chainCfg, logger, b.sysErr, b.tk, b.genni, rc.Users)
+	case config.Btc:
+		return btc.New(chainCfg, logger, b.sysErr, b.tk, b.genni, rc.Users)
+	case config.Doge:
+		return doge.New(chainCfg, logger, b.sysErr, b.tk, b.genni, rc.Users)
 	default:
 		return eth.InitializeChain(chainCfg, logger, b.sysErr, b.tk, b.genni, rc.Users)
 	}
@@ -106,6 +119,8 @@ func run(ctx *cli.Context) error {
 		return err
 	}
 
+	price.SetDefault(price.FromConfig(cfg.Price))
+
 	sysErr := make(chan error)
 	c := core.New(sysErr)
 	mapChain := cfg.MapChainConfig()
@@ -122,6 +137,7 @@ func run(ctx *cli.Context) error {
 	builder := &chainBuilder{
 		mapChainID:   mapChain.Id,
 		keystorePath: cfg.KeystorePath,
+		blockstore:   cfg.BlockstorePath,
 		tk:           &cfg.Tk,
 		genni:        &cfg.Genni,
 		sysErr:       sysErr,
@@ -151,6 +167,13 @@ func run(ctx *cli.Context) error {
 		cfgPath = config.DefaultConfigPath
 	}
 	go config.WatchSignals(rctx, store, cfgPath)
+	if cfg.StatusAddr != "" {
+		go func() {
+			if err := status.Serve(rctx, cfg.StatusAddr); err != nil {
+				log.Error("status api stopped", "addr", cfg.StatusAddr, "err", err)
+			}
+		}()
+	}
 	go applyReloads(rctx, store, c, builder)
 
 	c.Start()
@@ -177,6 +200,9 @@ func applyReloads(ctx context.Context, store *config.Store, c *core.Core, builde
 			diff := config.DiffChains(prev.Chains, newCfg.Chains)
 			builder.tk = &newCfg.Tk
 			builder.genni = &newCfg.Genni
+			if !reflect.DeepEqual(prev.Price, newCfg.Price) {
+				price.SetDefault(price.FromConfig(newCfg.Price))
+			}
 
 			for _, name := range diff.Removes {
 				if err := c.Remove(name); err != nil {
@@ -21,8 +21,13 @@ func ApplyHotReloadable(target, source *OptConfig) {
 		panic("config: ApplyHotReloadable called with nil source")
 	}
 	target.WaterLine = source.WaterLine
+	target.Symbol = source.Symbol
+	target.Multicall = source.Multicall
+	target.Nonce = source.Nonce
+	target.Gas = source.Gas
 	target.LightNode = source.LightNode
 	target.ApiUrl = source.ApiUrl
+	target.UtxoWaterLine = source.UtxoWaterLine
 	target.From = source.From
 	target.Users = source.Users
 	target.ContractToken = source.ContractToken
@@ -29,6 +29,7 @@ func TestApplyHotReloadable_OverwritesAllReloadableFields(t *testing.T) {
 		Genni:         oldGenni,
 		LightNode:     common.HexToAddress("0xaaaa"),
 		ApiUrl:        "old-api",
+		UtxoWaterLine: 5,
 	}
 
 	newTk := &Token{BridgeAddr: "new-bridge"}
@@ -50,6 +51,7 @@ func TestApplyHotReloadable_OverwritesAllReloadableFields(t *testing.T) {
 		Genni:         newGenni,
 		LightNode:     common.HexToAddress("0xbbbb"),
 		ApiUrl:        "new-api",
+		UtxoWaterLine: 8,
 	}
 
 	ApplyHotReloadable(target, source)
@@ -84,6 +86,9 @@ func TestApplyHotReloadable_OverwritesAllReloadableFields(t *testing.T) {
 	if target.ApiUrl != "new-api" {
 		t.Errorf("ApiUrl = %q, want new-api", target.ApiUrl)
 	}
+	if target.UtxoWaterLine != 8 {
+		t.Errorf("UtxoWaterLine = %d, want 8", target.UtxoWaterLine)
+	}
 }
 
 // TestApplyHotReloadable_PreservesImmutableFields verifies that fields
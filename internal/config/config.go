@@ -8,9 +8,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/shopspring/decimal"
 	"github.com/urfave/cli/v2"
 )
 
@@ -28,6 +32,7 @@ type RawChainConfig struct {
 	ContractToken []ContractToken   `json:"contractToken"`
 	Energies      []Energy          `json:"energy"`
 	Tss           *Tss              `json:"tss"`
+	Scan          *Scan             `json:"scan,omitempty"`
 }
 
 type Defaults struct {
@@ -43,6 +48,23 @@ type Config struct {
 	KeystorePath string           `json:"keystorePath,omitempty"`
 	Tk           Token            `json:"token"`
 	Genni        Api              `json:"genni"`
+	Price        *Price           `json:"price,omitempty"`
+	// BlockstorePath is the directory of the scanner checkpoints, ./monitor
+	// when empty.
+	BlockstorePath string `json:"blockstorePath,omitempty"`
+	StatusAddr     string `json:"statusAddr,omitempty"` // e.g. ":8080", serves /status; empty disables
+}
+
+// Price configures the JSON HTTP feed used to value native tokens in USD.
+// Url may contain "{symbol}"; Field and TimeField are dotted paths into the
+// response, TimeField holding unix seconds.
+type Price struct {
+	Url        string `json:"url"`
+	Field      string `json:"field,omitempty"`      // defaults to "price"
+	TimeField  string `json:"timeField,omitempty"`  // quotes are dated when fetched without it
+	Timeout    int64  `json:"timeout,omitempty"`    // seconds
+	CacheTTL   int64  `json:"cacheTTL,omitempty"`   // seconds, default 60
+	StaleAfter int64  `json:"staleAfter,omitempty"` // seconds, default 900
 }
 
 // MapChainConfig returns the map chain config from the chains list.
@@ -61,23 +83,111 @@ type Token struct {
 	MapBridge     string   `json:"map_bridge"`
 	Token         []string `json:"token"`
 	Contracts     []string `json:"contracts"`
+	Esplora       *Esplora `json:"esplora,omitempty"` // BTC balance of BtcBridgeAddr, mempool.space mainnet if nil
+	Okx           *OkxApi  `json:"okx,omitempty"`
+	// Providers lists the BRC-20 balance providers ("genii", "okx") in
+	// priority order. Empty means genii, then okx when configured.
+	Providers []string `json:"providers,omitempty"`
+	// Tolerance is the relative difference (0.001 = 0.1%) allowed between
+	// providers before alarming; 0 requires them to agree exactly.
+	Tolerance float64 `json:"tolerance,omitempty"`
+	// Reconciles are the bridge solvency rules checked on the map chain.
+	// Empty derives one rule per Token entry (see pkg/monitor).
+	Reconciles []Reconcile `json:"reconciles,omitempty"`
+	// Offsets adds a fixed amount (human units) to the bridge balance of a
+	// BRC-20 tick in the derived rules.
+	Offsets map[string]string `json:"offsets,omitempty"`
+}
+
+// OkxApi holds the OKX explorer credentials. Key, Passphrase and SecretKey
+// are comma separated lists of the same length; each set is tried in turn.
+type OkxApi struct {
+	Endpoint   string `json:"endpoint"`
+	Project    string `json:"project"`
+	Key        string `json:"key"`
+	Passphrase string `json:"passphrase"`
+	SecretKey  string `json:"secretKey"`
 }
 
 type ContractToken struct {
 	Address string     `json:"address"`
 	Tokens  []EthToken `json:"tokens"`
+	// Outflow sets the native and window outflow alarms of Address; nil
+	// leaves only the MaxTransfer of the tokens. Both are read by the
+	// chain's block scanner and need a scan entry.
+	Outflow *Outflow `json:"outflow,omitempty"`
+}
+
+// watchesOutflows reports whether ct sets any alarm fed by the block scanner.
+func (ct *ContractToken) watchesOutflows() bool {
+	if ct.Outflow != nil {
+		return true
+	}
+	for _, tk := range ct.Tokens {
+		if tk.MaxTransfer > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Outflow holds the outflow thresholds of a contractToken holder on an EVM
+// chain with a scan entry.
+type Outflow struct {
+	MaxNative string  `json:"maxNative,omitempty"` // native coins one transfer may send, empty disables
+	Window    int64   `json:"window,omitempty"`    // seconds of the rolling window, 0 disables the window alarm
+	Percent   float64 `json:"percent,omitempty"`   // percent of the holder's balance moved out within Window that alarms
+	Traces    bool    `json:"traces,omitempty"`    // read native transfers with trace_filter, including those of contract calls
+}
+
+func (o *Outflow) validate() error {
+	if o.MaxNative != "" {
+		if _, err := amount.Parse(o.MaxNative, EvmDecimals); err != nil {
+			return fmt.Errorf("maxNative %q: %w", o.MaxNative, err)
+		}
+	}
+	if o.Window < 0 || o.Percent < 0 || o.Percent > 100 {
+		return fmt.Errorf("window must not be negative and percent must be within 0-100")
+	}
+	if o.Window > 0 && o.Percent == 0 {
+		return fmt.Errorf("window needs a percent")
+	}
+	return nil
 }
 
 type Energy struct {
-	Address   string `json:"address"`
-	Waterline int64  `json:"waterline"`
+	Address            string `json:"address"`
+	Waterline          int64  `json:"waterline"`
+	BandwidthWaterline int64  `json:"bandwidthWaterline"` // staked plus free bandwidth left, 0 disables
+	ExpireWarn         int64  `json:"expireWarn"`         // seconds before a delegation or stake expires, default one day
+}
+
+// ExpireWarnAfter is how long before expiry delegated and frozen resources
+// of e alarm.
+func (e Energy) ExpireWarnAfter() time.Duration {
+	if e.ExpireWarn <= 0 {
+		return DefaultResourceExpireWarn
+	}
+	return time.Duration(e.ExpireWarn) * time.Second
 }
 
 type EthToken struct {
 	Name      string  `json:"name"`
 	Addr      string  `json:"addr"`
 	WaterLine float64 `json:"waterLine"`
-	Wei       int64   `json:"wei"`
+	Wei       int64   `json:"wei,omitempty"`    // optional, the eth and tron monitors read decimals() from the chain
+	Symbol    string  `json:"symbol,omitempty"` // optional, checked against symbol()
+	// MaxTransfer (whole tokens) alarms on any single Transfer out of the
+	// holder above it, on EVM chains with a scan entry; 0 disables.
+	MaxTransfer float64 `json:"maxTransfer,omitempty"`
+}
+
+// Decimals returns the token decimals configured as Wei, 18 when unset.
+func (t EthToken) Decimals() int32 {
+	if t.Wei == 0 {
+		return 18
+	}
+	return int32(t.Wei)
 }
 
 type Api struct {
@@ -98,6 +208,229 @@ type Tss struct {
 	BlockstreamUrl string `json:"blockstreamUrl,omitempty"`
 	TssApiUrl      string `json:"tssApiUrl,omitempty"`
 	CrossTxLimit   int    `json:"crossTxLimit,omitempty"`
+	// StaleAfter is the SLA in seconds of the routes that do not set one,
+	// 7200 when 0.
+	StaleAfter int64 `json:"staleAfter,omitempty"`
+	// StatusOk is the TSS API status of a completed transfer, 3 when nil.
+	StatusOk *int `json:"statusOk,omitempty"`
+	// Statuses overrides DefaultCrossTxStatuses by TSS API status_str.
+	Statuses map[string]CrossTxStatus `json:"statuses,omitempty"`
+	// CrossTxSources lists bridge contracts whose outbound transactions are
+	// tracked through TssApiUrl besides those of BtcAddress.
+	CrossTxSources []CrossTxSource `json:"crossTxSources,omitempty"`
+	// StorePath is the directory the status history of tracked cross-chain
+	// transactions is kept in; empty uses ./monitor.
+	StorePath string `json:"storePath,omitempty"`
+	// Esplora takes precedence over BlockstreamUrl for listing BtcAddress transactions.
+	Esplora *Esplora `json:"esplora,omitempty"`
+	// PendingOutflowWaterLine (BTC) alarms when unconfirmed transactions move
+	// more than this out of BtcAddress; empty disables the check.
+	PendingOutflowWaterLine string `json:"pendingOutflowWaterLine,omitempty"`
+	// Utxo sets the UTXO health thresholds of BtcAddress; nil disables the check.
+	Utxo *UtxoHealth `json:"utxo,omitempty"`
+	// Health sets the on-chain liveness thresholds of the elected
+	// maintainers; nil disables the check.
+	Health *MaintainerHealth `json:"health,omitempty"`
+	// Epoch sets the epoch lifecycle thresholds of the maintainer contract;
+	// nil disables the check.
+	Epoch *EpochCheck `json:"epoch,omitempty"`
+	// Probe sets how maintainer status endpoints are reached; nil probes
+	// http://<p2pAddress>:6040.
+	Probe *MaintainerProbe `json:"probe,omitempty"`
+	// Quorum sets the signing threshold the healthy maintainers are held
+	// against; nil disables the check.
+	Quorum *Quorum `json:"quorum,omitempty"`
+	// Mesh tunes the P2P mesh consistency check; nil uses the defaults.
+	Mesh *MeshCheck `json:"mesh,omitempty"`
+}
+
+// MeshCheck sets when a maintainer's dial time to a peer is an outlier:
+// above LatencyFactor times the median dial of the mesh and above
+// MinOutlierMs.
+type MeshCheck struct {
+	LatencyFactor float64 `json:"latencyFactor,omitempty"` // default 3
+	MinOutlierMs  int     `json:"minOutlierMs,omitempty"`  // default 200
+}
+
+// Quorum holds the TSS signing threshold. A critical alarm is raised when
+// no more than Threshold+Margin maintainers are healthy: by default once the
+// TSS has no spare maintainer left and one more failure stops signing.
+type Quorum struct {
+	Threshold int `json:"threshold"`        // maintainers needed to sign
+	Margin    int `json:"margin,omitempty"` // healthy maintainers above Threshold that still alarm
+}
+
+// AlarmAt is the healthy maintainer count at or below which q alarms.
+func (q *Quorum) AlarmAt() int {
+	return q.Threshold + q.Margin
+}
+
+// MaintainerProbe configures the client probing /ping, /status/p2p and
+// /status/scanner of every maintainer.
+type MaintainerProbe struct {
+	Port        int    `json:"port,omitempty"`        // default 6040
+	Scheme      string `json:"scheme,omitempty"`      // http (default) or https
+	CaFile      string `json:"caFile,omitempty"`      // PEM bundle trusted for https, system roots when empty
+	Timeout     int64  `json:"timeout,omitempty"`     // seconds per request, default 10
+	Concurrency int    `json:"concurrency,omitempty"` // maintainers probed at once, default 8
+}
+
+// EpochCheck holds the thresholds of the TSS epoch lifecycle, in MAP blocks.
+type EpochCheck struct {
+	OverdueBlocks   uint64 `json:"overdueBlocks,omitempty"`   // blocks past EndBlock before a missing rotation alarms
+	MigrationBlocks uint64 `json:"migrationBlocks,omitempty"` // blocks after StartBlock for key migration to finish, 0 disables
+	Maintainers     int    `json:"maintainers,omitempty"`     // expected maintainer count, 0 alarms on any change in size
+}
+
+// MaintainerHealth holds the thresholds checked against the MaintainerInfo
+// of every maintainer elected in the current epoch. An unset threshold
+// disables its alarm.
+type MaintainerHealth struct {
+	MaxHeartbeatAge int64   `json:"maxHeartbeatAge,omitempty"` // seconds since LastHeartbeatTime
+	ActiveStatus    *uint8  `json:"activeStatus,omitempty"`    // the MaintainerStatus of a working maintainer
+	MaxEpochLag     *uint64 `json:"maxEpochLag,omitempty"`     // epochs LastActiveEpoch may trail currentEpoch
+}
+
+// CrossTxStatus sets how a transfer the TSS API reports in one status_str
+// is handled.
+type CrossTxStatus struct {
+	Severity string `json:"severity,omitempty"` // info, warning (default) or critical
+	After    *int64 `json:"after,omitempty"`    // seconds before it alarms, the route SLA when nil, 0 alarms at once
+	Final    bool   `json:"final,omitempty"`    // the transfer will not complete, stop following it
+}
+
+// RouteLimit is the number of recent transactions checked of the routes
+// that do not set a limit.
+func (t *Tss) RouteLimit() int {
+	if t.CrossTxLimit <= 0 {
+		return DefaultCrossTxLimit
+	}
+	return t.CrossTxLimit
+}
+
+// StaleAfterDuration is the SLA of the routes that do not set one.
+func (t *Tss) StaleAfterDuration() time.Duration {
+	if t.StaleAfter <= 0 {
+		return DefaultCrossTxStaleAfter
+	}
+	return time.Duration(t.StaleAfter) * time.Second
+}
+
+// OkStatus is the TSS API status of a completed transfer.
+func (t *Tss) OkStatus() int {
+	if t.StatusOk == nil {
+		return DefaultCrossTxStatusOk
+	}
+	return *t.StatusOk
+}
+
+// CrossTxStatus returns how a transfer in statusStr is handled: the entry of
+// Statuses, else of DefaultCrossTxStatuses, else alarms at the route SLA.
+func (t *Tss) CrossTxStatus(statusStr string) CrossTxStatus {
+	if st, ok := t.Statuses[statusStr]; ok {
+		return st
+	}
+	return DefaultCrossTxStatuses[statusStr]
+}
+
+func (st CrossTxStatus) validate() error {
+	switch st.Severity {
+	case "", "info", "warning", "critical":
+	default:
+		return fmt.Errorf("severity %q is not info, warning or critical", st.Severity)
+	}
+	if st.After != nil && *st.After < 0 {
+		return fmt.Errorf("after %d is negative", *st.After)
+	}
+	return nil
+}
+
+// CrossTxSource is one route whose outbound bridge transactions are
+// tracked: EVM logs of Bridge in the last Blocks blocks, the transactions
+// sent to Bridge on Tron (Endpoint is a TronGrid API) or the signatures of
+// the Bridge program on Solana.
+type CrossTxSource struct {
+	Name     string   `json:"name"`             // route label used in alarms, e.g. "eth->btc"
+	Type     string   `json:"type,omitempty"`   // ethereum (default), tron or sol
+	Endpoint string   `json:"endpoint"`         // RPC of the source chain
+	Bridge   string   `json:"bridge"`           // bridge contract or program
+	Topics   []string `json:"topics,omitempty"` // EVM only, event signatures (topic0) to track, all logs when empty
+	Blocks   uint64   `json:"blocks,omitempty"` // EVM only, recent blocks scanned, default 1000
+	Limit    int      `json:"limit,omitempty"`  // most recent transactions checked, default crossTxLimit
+	Sla      int64    `json:"sla,omitempty"`    // seconds before an incomplete transfer alarms, default 7200
+}
+
+// TopicHashes returns the topic0 hashes of topics, each a 0x-prefixed hash
+// or an event signature such as "Transfer(address,address,uint256)".
+func TopicHashes(topics []string) []common.Hash {
+	var hashes []common.Hash
+	for _, topic := range topics {
+		if strings.HasPrefix(topic, "0x") && len(topic) == 66 {
+			hashes = append(hashes, common.HexToHash(topic))
+		} else {
+			hashes = append(hashes, crypto.Keccak256Hash([]byte(topic)))
+		}
+	}
+	return hashes
+}
+
+// Scan configures the block scanner of an EVM chain: every scanned range is
+// handed to the chain's event checks (with the logs of Contracts matching
+// Topics to those that take them), and the last block handled is
+// checkpointed under the blockstore path so a restart resumes where the
+// scanner stopped.
+type Scan struct {
+	Contracts     []string `json:"contracts,omitempty"`     // contract addresses whose logs are scanned
+	Topics        []string `json:"topics,omitempty"`        // event signatures or topic hashes (topic0), all when empty
+	StartBlock    uint64   `json:"startBlock,omitempty"`    // first block scanned without a checkpoint, head when 0
+	Confirmations uint64   `json:"confirmations,omitempty"` // blocks behind head left unscanned, default 6
+	Batch         uint64   `json:"batch,omitempty"`         // blocks per eth_getLogs, default 500
+	Interval      int64    `json:"interval,omitempty"`      // seconds between polls once caught up, default 15
+}
+
+func (s *Scan) validate() error {
+	for _, c := range s.Contracts {
+		if !common.IsHexAddress(c) {
+			return fmt.Errorf("contract %q is not an address", c)
+		}
+	}
+	return nil
+}
+
+// UtxoHealth holds the UTXO health thresholds of the TSS BTC vault. Amounts
+// are in BTC; a zero or empty threshold disables its alarm.
+type UtxoHealth struct {
+	MinCount       int    `json:"minCount,omitempty"`       // fewer UTXOs serialise withdrawals
+	MaxCount       int    `json:"maxCount,omitempty"`       // more UTXOs make withdrawals expensive
+	DustValue      string `json:"dustValue,omitempty"`      // UTXOs below this are dust, default 0.0001
+	MaxDust        int    `json:"maxDust,omitempty"`        // alarm above this many dust UTXOs
+	MinLargest     string `json:"minLargest,omitempty"`     // alarm when the largest UTXO is below this
+	MinSmallest    string `json:"minSmallest,omitempty"`    // alarm when the smallest UTXO is below this
+	MaxUnconfirmed int    `json:"maxUnconfirmed,omitempty"` // alarm above this many unconfirmed UTXOs
+}
+
+// Esplora points BTC lookups at an Esplora-compatible API. Url may be empty
+// to use the public mempool.space API of Network (mainnet, testnet or signet).
+type Esplora struct {
+	Url           string `json:"url,omitempty"`
+	Network       string `json:"network,omitempty"`
+	Timeout       int64  `json:"timeout,omitempty"`       // seconds per request
+	Retries       int    `json:"retries,omitempty"`       // extra attempts on transport errors, 429 and 5xx
+	RetryInterval int64  `json:"retryInterval,omitempty"` // seconds between attempts
+	AuthHeader    string `json:"authHeader,omitempty"`
+	AuthValue     string `json:"authValue,omitempty"`
+}
+
+func (src CrossTxSource) validate() error {
+	if src.Name == "" || src.Endpoint == "" || src.Bridge == "" {
+		return fmt.Errorf("name, endpoint and bridge are required, got %+v", src)
+	}
+	switch src.Type {
+	case "", "ethereum", Tron, Sol:
+	default:
+		return fmt.Errorf("%s type %q is not ethereum, tron or sol", src.Name, src.Type)
+	}
+	return nil
 }
 
 func (c *Config) validate() error {
@@ -108,10 +441,67 @@ func (c *Config) validate() error {
 		if chain.Name == "" {
 			return fmt.Errorf("required field chains.Name empty for chain with id %s", chain.Id)
 		}
+		if err := validateWaterLines(&chain, c.Price != nil); err != nil {
+			return err
+		}
+		if chain.Tss != nil {
+			for _, src := range chain.Tss.CrossTxSources {
+				if err := src.validate(); err != nil {
+					return fmt.Errorf("chain %s tss.crossTxSources: %w", chain.Name, err)
+				}
+			}
+			for name, st := range chain.Tss.Statuses {
+				if err := st.validate(); err != nil {
+					return fmt.Errorf("chain %s tss.statuses.%s: %w", chain.Name, name, err)
+				}
+			}
+			if chain.Tss.StaleAfter < 0 {
+				return fmt.Errorf("chain %s tss.staleAfter %d is negative", chain.Name, chain.Tss.StaleAfter)
+			}
+		}
+		for _, ct := range chain.ContractToken {
+			// outflows are only read by the block scanner
+			if chain.Scan == nil && ct.watchesOutflows() {
+				return fmt.Errorf("chain %s contractToken %s outflow and maxTransfer need a scan entry", chain.Name, ct.Address)
+			}
+			if ct.Outflow == nil {
+				continue
+			}
+			if err := ct.Outflow.validate(); err != nil {
+				return fmt.Errorf("chain %s contractToken %s outflow: %w", chain.Name, ct.Address, err)
+			}
+		}
+		if chain.Scan != nil {
+			if err := chain.Scan.validate(); err != nil {
+				return fmt.Errorf("chain %s scan: %w", chain.Name, err)
+			}
+		}
+		if chain.Tss != nil && chain.Tss.Quorum != nil {
+			if q := chain.Tss.Quorum; q.Threshold <= 0 || q.Margin < 0 {
+				return fmt.Errorf("chain %s tss.quorum needs a positive threshold and a non-negative margin", chain.Name)
+			}
+			// with a zero gap every scanner fails the probe and no node counts as healthy
+			if chain.Tss.ScannerGap <= 0 {
+				return fmt.Errorf("chain %s tss.quorum needs a positive tss.scannerGap", chain.Name)
+			}
+		}
 	}
 	if mc := c.MapChainConfig(); mc == nil {
 		return fmt.Errorf("map chain not found in chains list, please add a chain with name \"map\"")
 	}
+	if c.Price != nil && c.Price.Url == "" {
+		return fmt.Errorf("required field price.url empty")
+	}
+	for i := range c.Tk.Reconciles {
+		if err := c.Tk.Reconciles[i].validate(); err != nil {
+			return err
+		}
+	}
+	for tick, offset := range c.Tk.Offsets {
+		if _, err := decimal.NewFromString(offset); err != nil {
+			return fmt.Errorf("token.offsets %s %q Not Number", tick, offset)
+		}
+	}
 	return nil
 }
 
@@ -254,6 +644,30 @@ func loadConfig(file string, config *Config) error {
 	return nil
 }
 
+// NonceCheck configures the nonce gap alarms of EVM sender accounts: a gap
+// of at least Gap pending transactions lasting GapAfter, or pending
+// transactions while the confirmed nonce has not moved for StallAfter. A
+// zero duration turns that alarm off.
+type NonceCheck struct {
+	Gap        uint64
+	GapAfter   time.Duration
+	StallAfter time.Duration
+}
+
+// GasCheck configures the gas alarms of EVM chains. AlarmPrice ceils the
+// base fee plus priority fee (the legacy gas price before London) and
+// MaxPriorityFee the priority fee, both in wei and set in gwei; nil turns
+// the ceiling off. AlarmPrice only alarms, unlike OptConfig.MaxGasPrice,
+// which caps what the connection pays.
+// Relays affordable by a sender are its balance over RelayGas at the
+// current gas price, alarmed below MinRelays when it is set.
+type GasCheck struct {
+	AlarmPrice     *big.Int
+	MaxPriorityFee *big.Int
+	RelayGas       uint64
+	MinRelays      uint64
+}
+
 type OptConfig struct {
 	Name           string   // Human-readable chain name
 	Id             ChainId  // ChainID
@@ -264,6 +678,10 @@ type OptConfig struct {
 	MaxGasPrice    *big.Int
 	GasMultiplier  *big.Float
 	WaterLine      string
+	Symbol         string         // native token symbol used for USD valuation
+	Multicall      common.Address // Multicall3 used to batch balance reads, zero for JSON-RPC batches
+	Nonce          NonceCheck
+	Gas            GasCheck
 	ChangeInterval string
 	ApiUrl         string
 	StartBlock     *big.Int
@@ -272,6 +690,7 @@ type OptConfig struct {
 	Tk             *Token
 	Genni          *Api
 	CheckHgtCount  int64
+	UtxoWaterLine  int64 // fewest UTXOs an address may hold, 0 disables the check
 	Users          []From
 	ContractToken  []ContractToken
 	Energies       []Energy
@@ -299,6 +718,14 @@ func ParseOptConfig(chainCfg *ChainConfig, tks *Token, genni *Api, users []From)
 		Energies:       chainCfg.Energies,
 		Users:          users,
 		Tss:            chainCfg.Tss,
+		Symbol:         NativeSymbol(chainCfg.Type, chainCfg.Opts),
+		Multicall:      DefaultMulticall3,
+		Gas:            GasCheck{RelayGas: DefaultGasLimit},
+		Nonce: NonceCheck{
+			Gap:        DefaultNonceGap,
+			GapAfter:   DefaultNonceGapAfter,
+			StallAfter: DefaultNonceStallAfter,
+		},
 	}
 
 	if chainCfg.NearKeystorePath != "" {
@@ -326,10 +753,76 @@ func ParseOptConfig(chainCfg *ChainConfig, tks *Token, genni *Api, users []From)
 		config.ChangeInterval = alarmSecond
 	}
 
+	if multicall, ok := chainCfg.Opts[Multicall]; ok && multicall != "" {
+		if strings.EqualFold(multicall, "none") {
+			config.Multicall = common.Address{}
+		} else if common.IsHexAddress(multicall) {
+			config.Multicall = common.HexToAddress(multicall)
+		} else {
+			return nil, fmt.Errorf("%s multicall %q is not an address", chainCfg.Name, multicall)
+		}
+	}
+
+	if gap, ok := chainCfg.Opts[NonceGap]; ok && gap != "" {
+		n, err := strconv.ParseUint(gap, 10, 64)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("%s nonceGap %q must be a positive integer", chainCfg.Name, gap)
+		}
+		config.Nonce.Gap = n
+	}
+	for key, target := range map[string]*time.Duration{
+		NonceGapAfter:   &config.Nonce.GapAfter,
+		NonceStallAfter: &config.Nonce.StallAfter,
+	} {
+		if value, ok := chainCfg.Opts[key]; ok && value != "" {
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || seconds < 0 {
+				return nil, fmt.Errorf("%s %s %q must be seconds", chainCfg.Name, key, value)
+			}
+			*target = time.Duration(seconds) * time.Second
+		}
+	}
+
+	for key, target := range map[string]**big.Int{
+		GasAlarmPrice:  &config.Gas.AlarmPrice,
+		MaxPriorityFee: &config.Gas.MaxPriorityFee,
+	} {
+		if value, ok := chainCfg.Opts[key]; ok && value != "" {
+			gwei, err := amount.Parse(value, 9)
+			if err != nil || gwei.Raw().Sign() <= 0 {
+				return nil, fmt.Errorf("%s %s %q must be a positive gwei amount", chainCfg.Name, key, value)
+			}
+			*target = gwei.Raw()
+		}
+	}
+	for key, target := range map[string]*uint64{
+		RelayGas:  &config.Gas.RelayGas,
+		MinRelays: &config.Gas.MinRelays,
+	} {
+		if value, ok := chainCfg.Opts[key]; ok && value != "" {
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s %q Not Number", chainCfg.Name, key, value)
+			}
+			*target = n
+		}
+	}
+	if config.Gas.RelayGas == 0 {
+		return nil, fmt.Errorf("%s relayGas must be positive", chainCfg.Name)
+	}
+
 	if apiUrl, ok := chainCfg.Opts[ApiUrl]; ok && apiUrl != "" {
 		config.ApiUrl = apiUrl
 	}
 
+	if utxoWaterLine, ok := chainCfg.Opts[UtxoWaterLine]; ok && utxoWaterLine != "" {
+		n, err := strconv.ParseInt(utxoWaterLine, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s utxoWaterLine %q must be a count", chainCfg.Name, utxoWaterLine)
+		}
+		config.UtxoWaterLine = n
+	}
+
 	if checkHeightCount, ok := chainCfg.Opts[CheckHeightCount]; ok && checkHeightCount != "" {
 		count, err := strconv.Atoi(checkHeightCount)
 		if err != nil {
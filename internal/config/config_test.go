@@ -0,0 +1,53 @@
+package config
+
+import "testing"
+
+func TestValidate_Quorum(t *testing.T) {
+	build := func(tss *Tss) *Config {
+		return &Config{Chains: []RawChainConfig{{Name: "map", Endpoint: "http://map", Tss: tss}}}
+	}
+
+	if err := build(&Tss{ScannerGap: 50, Quorum: &Quorum{Threshold: 4}}).validate(); err != nil {
+		t.Fatalf("valid quorum rejected: %v", err)
+	}
+	invalid := map[string]*Tss{
+		"no threshold": {ScannerGap: 50, Quorum: &Quorum{}},
+		"negative":     {ScannerGap: 50, Quorum: &Quorum{Threshold: 4, Margin: -1}},
+		"zero gap":     {Quorum: &Quorum{Threshold: 4}},
+		"negative gap": {ScannerGap: -1, Quorum: &Quorum{Threshold: 4}},
+	}
+	for name, tss := range invalid {
+		if err := build(tss).validate(); err == nil {
+			t.Fatalf("%s: expected validate to fail", name)
+		}
+	}
+}
+
+func TestValidate_OutflowNeedsScan(t *testing.T) {
+	build := func(ct ContractToken, scan *Scan) *Config {
+		return &Config{Chains: []RawChainConfig{
+			{Name: "map", Endpoint: "http://map"},
+			{Name: "eth", Endpoint: "http://eth", ContractToken: []ContractToken{ct}, Scan: scan},
+		}}
+	}
+	holder := "0x0000000000000000000000000000000000000001"
+	token := EthToken{Name: "usdt", Addr: "0x00000000000000000000000000000000000000aa", WaterLine: 1}
+	capped := token
+	capped.MaxTransfer = 1000
+
+	if err := build(ContractToken{Address: holder, Tokens: []EthToken{token}}, nil).validate(); err != nil {
+		t.Fatalf("waterLine only contractToken rejected: %v", err)
+	}
+	if err := build(ContractToken{Address: holder, Tokens: []EthToken{capped}}, &Scan{}).validate(); err != nil {
+		t.Fatalf("maxTransfer with scan rejected: %v", err)
+	}
+	invalid := map[string]ContractToken{
+		"maxTransfer": {Address: holder, Tokens: []EthToken{capped}},
+		"outflow":     {Address: holder, Outflow: &Outflow{MaxNative: "10"}},
+	}
+	for name, ct := range invalid {
+		if err := build(ct, nil).validate(); err == nil {
+			t.Fatalf("%s without scan: expected validate to fail", name)
+		}
+	}
+}
@@ -18,6 +18,20 @@ const (
 	Tron = "tron"
 	Sol  = "sol"
 	Xrp  = "xrp"
+	Btc  = "btc"
+	Doge = "doge"
+)
+
+// Native token decimals per chain type; waterLines are written in whole
+// tokens and scaled by these.
+const (
+	EvmDecimals  = 18
+	NearDecimals = 24
+	TronDecimals = 6
+	SolDecimals  = 9
+	XrpDecimals  = 6
+	BtcDecimals  = 8
+	DogeDecimals = 8
 )
 
 const (
@@ -37,6 +51,48 @@ const (
 	DefaultCheckHgtCount = 15
 )
 
+// Nonce gap defaults for EVM sender accounts.
+const (
+	DefaultNonceGap        = 1
+	DefaultNonceGapAfter   = 10 * time.Minute
+	DefaultNonceStallAfter = 5 * time.Minute
+)
+
+// DefaultResourceExpireWarn is how long before a tron delegation or stake
+// expires that it alarms, when the energy entry does not set expireWarn.
+const DefaultResourceExpireWarn = 24 * time.Hour
+
+// Cross-chain transfer tracking defaults.
+const (
+	DefaultCrossTxLimit      = 50
+	DefaultCrossTxStaleAfter = 2 * time.Hour
+	DefaultCrossTxStatusOk   = 3
+)
+
+var alarmAtOnce int64 // After of the statuses that alarm without waiting
+
+// DefaultCrossTxStatuses alarms failed and refunded transfers at once and
+// stops following them, and keeps transfers waiting for confirmations quiet
+// until the route SLA.
+var DefaultCrossTxStatuses = map[string]CrossTxStatus{
+	"failed":                {Severity: "critical", After: &alarmAtOnce, Final: true},
+	"refunded":              {Severity: "warning", After: &alarmAtOnce, Final: true},
+	"pending-confirmations": {Severity: "info"},
+}
+
+// EVM block scanner defaults.
+const (
+	DefaultScanConfirmations = 6
+	DefaultScanBatch         = 500
+	DefaultScanInterval      = 15 * time.Second
+)
+
+// P2P mesh dial latency outlier defaults.
+const (
+	DefaultMeshLatencyFactor = 3.0
+	DefaultMeshMinOutlierMs  = 200
+)
+
 // Chain specific options
 var (
 	LightNode        = "lightnode"
@@ -44,6 +100,17 @@ var (
 	ChangeInterval   = "changeInterval"
 	CheckHeightCount = "checkHeightCount"
 	ApiUrl           = "apiUrl"
+	Indexer          = "indexer"
+	UtxoWaterLine    = "utxoWaterLine"
+	Symbol           = "symbol"
+	Multicall        = "multicall"
+	NonceGap         = "nonceGap"
+	NonceGapAfter    = "nonceGapAfter"
+	NonceStallAfter  = "nonceStallAfter"
+	GasAlarmPrice    = "gasAlarmPrice"
+	MaxPriorityFee   = "maxPriorityFee"
+	RelayGas         = "relayGas"
+	MinRelays        = "minRelays"
 )
 
 const (
@@ -52,6 +119,8 @@ const (
 
 var (
 	ZeroAddress = common.HexToAddress("0x0000000000000000000000000000000000000000")
+	// DefaultMulticall3 is the address Multicall3 is deployed at on most EVM chains.
+	DefaultMulticall3 = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
 )
 
 var (
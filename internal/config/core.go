@@ -2,6 +2,7 @@ package config
 
 type ChainConfig struct {
 	Name             string            // Human-readable chains name
+	Type             string            // chain type, e.g. "tron"; EVM when unknown
 	Id               ChainId           // ChainID
 	Endpoint         string            // url for rpc endpoint
 	Network          string            //
@@ -16,4 +17,5 @@ type ChainConfig struct {
 	ContractToken    []ContractToken
 	Energies         []Energy
 	Tss              *Tss
+	Scan             *Scan
 }
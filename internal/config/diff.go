@@ -13,7 +13,8 @@ import (
 //   - Restarts: same name, but a structural field changed      (Stop + Start with new build)
 //   - Updates:  same name, only data fields changed            (in-place ApplyHotReloadable)
 //
-// Structural means the field can't be mutated in place: Endpoint, Network.
+// Structural means the field can't be mutated in place: Endpoint, Network,
+// Scan, opts.indexer.
 // Other immutable fields (Type, Id, KeystorePath, opts.checkHeightCount,
 // opts.changeInterval) are filtered out earlier by diffImmutable in the
 // reloader, so DiffChains assumes the input is already validated.
@@ -60,7 +61,8 @@ func DiffChains(old, new []RawChainConfig) ChainDiff {
 // structuralChanged reports whether oc -> nc requires tearing down the
 // chain (its Connection) and starting a fresh one.
 func structuralChanged(oc, nc RawChainConfig) bool {
-	return oc.Endpoint != nc.Endpoint || oc.Network != nc.Network
+	return oc.Endpoint != nc.Endpoint || oc.Network != nc.Network || !reflect.DeepEqual(oc.Scan, nc.Scan) ||
+		optsValue(oc.Opts, Indexer) != optsValue(nc.Opts, Indexer)
 }
 
 // dataChanged reports whether any hot-reloadable field differs. We compare
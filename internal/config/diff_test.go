@@ -81,6 +81,31 @@ func TestDiffChains_NetworkChangeRestarts(t *testing.T) {
 	}
 }
 
+func TestDiffChains_ScanChangeRestarts(t *testing.T) {
+	old := []RawChainConfig{chainMAP(), chainBSC()}
+	new := []RawChainConfig{chainMAP(), chainBSC(func(c *RawChainConfig) { c.Scan = &Scan{Contracts: []string{"0x01"}} })}
+
+	d := DiffChains(old, new)
+
+	if got := names(d.Restarts); !reflect.DeepEqual(got, []string{"bsc"}) {
+		t.Errorf("Restarts = %v, want [bsc]", got)
+	}
+}
+
+func TestDiffChains_IndexerChangeRestarts(t *testing.T) {
+	old := []RawChainConfig{chainMAP(), chainBSC()}
+	new := []RawChainConfig{chainMAP(), chainBSC(func(c *RawChainConfig) { c.Opts = map[string]string{Indexer: "blockbook"} })}
+
+	d := DiffChains(old, new)
+
+	if got := names(d.Restarts); !reflect.DeepEqual(got, []string{"bsc"}) {
+		t.Errorf("Restarts = %v, want [bsc]", got)
+	}
+	if len(d.Updates) != 0 {
+		t.Errorf("Updates = %v, want none", names(d.Updates))
+	}
+}
+
 func TestDiffChains_DataOnlyChangeUpdates(t *testing.T) {
 	old := []RawChainConfig{chainMAP(), chainBSC(func(c *RawChainConfig) {
 		c.Users = []From{{Group: "g1", From: "0xa"}}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Kinds of reconciliation terms.
+const (
+	TermEvmBalance     = "evmBalance"     // ERC-20 balanceOf(Holder) of Token on MAP
+	TermEvmNative      = "evmNative"      // native balance of Holder on MAP
+	TermEvmTotalSupply = "evmTotalSupply" // ERC-20 totalSupply of Token on MAP
+	TermMinterTotal    = "minterTotal"    // minterCap(Holder).total of Token on MAP
+	TermBtcBalance     = "btcBalance"     // confirmed BTC balance of Holder
+	TermBrc20Balance   = "brc20Balance"   // BRC-20 balance of tick Token held by Holder
+	TermManual         = "manual"         // fixed Amount
+)
+
+// Reconcile is one solvency rule: the sum of Sources (what backs the bridge)
+// must not fall below the sum of Sinks (what was issued against it) by more
+// than Tolerance. Tolerance and manual amounts are in human units.
+type Reconcile struct {
+	Name      string          `json:"name"`
+	Tolerance string          `json:"tolerance,omitempty"`
+	Sources   []ReconcileTerm `json:"sources"`
+	Sinks     []ReconcileTerm `json:"sinks"`
+}
+
+// ReconcileTerm is one value of a rule. Decimals converts the raw value the
+// kind returns to human units; Subtract makes the term count negatively,
+// e.g. the tokens locked in the MAP bridge.
+type ReconcileTerm struct {
+	Kind     string `json:"kind"`
+	Token    string `json:"token,omitempty"`  // contract address, or BRC-20 tick
+	Holder   string `json:"holder,omitempty"` // comma separated addresses
+	Decimals int32  `json:"decimals,omitempty"`
+	Amount   string `json:"amount,omitempty"` // manual only
+	Subtract bool   `json:"subtract,omitempty"`
+}
+
+func (r *Reconcile) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("required field token.reconciles.name empty")
+	}
+	if len(r.Sources) == 0 || len(r.Sinks) == 0 {
+		return fmt.Errorf("reconcile %s needs at least one source and one sink", r.Name)
+	}
+	if r.Tolerance != "" {
+		if _, err := decimal.NewFromString(r.Tolerance); err != nil {
+			return fmt.Errorf("reconcile %s tolerance %q Not Number", r.Name, r.Tolerance)
+		}
+	}
+	for _, terms := range [][]ReconcileTerm{r.Sources, r.Sinks} {
+		for _, term := range terms {
+			if err := term.validate(); err != nil {
+				return fmt.Errorf("reconcile %s: %w", r.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *ReconcileTerm) validate() error {
+	if t.Decimals < 0 {
+		return fmt.Errorf("%s term decimals must not be negative", t.Kind)
+	}
+	switch t.Kind {
+	case TermEvmBalance, TermMinterTotal:
+		if t.Token == "" || t.Holder == "" {
+			return fmt.Errorf("%s term requires token and holder", t.Kind)
+		}
+	case TermEvmTotalSupply:
+		if t.Token == "" {
+			return fmt.Errorf("%s term requires token", t.Kind)
+		}
+	case TermEvmNative, TermBtcBalance:
+		if t.Holder == "" {
+			return fmt.Errorf("%s term requires holder", t.Kind)
+		}
+	case TermBrc20Balance:
+		if t.Token == "" {
+			return fmt.Errorf("%s term requires token tick", t.Kind)
+		}
+	case TermManual:
+		if _, err := decimal.NewFromString(t.Amount); err != nil {
+			return fmt.Errorf("manual term amount %q Not Number", t.Amount)
+		}
+	default:
+		return fmt.Errorf("unknown reconcile term kind %q", t.Kind)
+	}
+	return nil
+}
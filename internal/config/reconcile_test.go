@@ -0,0 +1,41 @@
+package config
+
+import "testing"
+
+func TestReconcileValidate(t *testing.T) {
+	valid := Reconcile{
+		Name:      "ordi",
+		Tolerance: "0.5",
+		Sources:   []ReconcileTerm{{Kind: TermBrc20Balance, Token: "ordi"}, {Kind: TermManual, Amount: "100"}},
+		Sinks:     []ReconcileTerm{{Kind: TermEvmTotalSupply, Token: "0xa", Decimals: 18}},
+	}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("valid rule rejected: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(r *Reconcile)
+	}{
+		{name: "missing name", edit: func(r *Reconcile) { r.Name = "" }},
+		{name: "no sinks", edit: func(r *Reconcile) { r.Sinks = nil }},
+		{name: "bad tolerance", edit: func(r *Reconcile) { r.Tolerance = "1%" }},
+		{name: "unknown kind", edit: func(r *Reconcile) { r.Sources[0].Kind = "erc721" }},
+		{name: "manual without amount", edit: func(r *Reconcile) { r.Sources[1].Amount = "" }},
+		{name: "balance without holder", edit: func(r *Reconcile) {
+			r.Sinks[0] = ReconcileTerm{Kind: TermEvmBalance, Token: "0xa"}
+		}},
+		{name: "negative decimals", edit: func(r *Reconcile) { r.Sinks[0].Decimals = -1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			rule.Sources = append([]ReconcileTerm(nil), valid.Sources...)
+			rule.Sinks = append([]ReconcileTerm(nil), valid.Sinks...)
+			tt.edit(&rule)
+			if err := rule.validate(); err == nil {
+				t.Fatal("expected validation error")
+			}
+		})
+	}
+}
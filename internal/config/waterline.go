@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"math/big"
 	"strings"
 
@@ -51,3 +52,101 @@ func shouldTreatAsSmallestUnit(value string, decimals int32) bool {
 	// large are not realistic for balance alarms, so keep those values compatible.
 	return len(digits) >= 12
 }
+
+// NativeDecimals returns the decimals of the native token of chainType.
+// Unknown types are EVM chains.
+func NativeDecimals(chainType string) int32 {
+	switch chainType {
+	case Near:
+		return NearDecimals
+	case Tron:
+		return TronDecimals
+	case Sol:
+		return SolDecimals
+	case Xrp:
+		return XrpDecimals
+	case Btc:
+		return BtcDecimals
+	case Doge:
+		return DogeDecimals
+	default:
+		return EvmDecimals
+	}
+}
+
+// NativeSymbol returns the native token symbol of a chain: opts.symbol when
+// set, else the symbol of chainType, or "" for EVM chains.
+func NativeSymbol(chainType string, opts map[string]string) string {
+	if symbol := strings.TrimSpace(opts[Symbol]); symbol != "" {
+		return strings.ToUpper(symbol)
+	}
+	switch chainType {
+	case Near:
+		return "NEAR"
+	case Tron:
+		return "TRX"
+	case Sol:
+		return "SOL"
+	case Xrp:
+		return "XRP"
+	case Btc:
+		return "BTC"
+	case Doge:
+		return "DOGE"
+	default:
+		return ""
+	}
+}
+
+// IsFiatWaterLine reports whether value is written in dollars, e.g. "$500".
+func IsFiatWaterLine(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), "$")
+}
+
+// ParseFiatWaterLine parses a dollar waterLine such as "$500" or "$12.5".
+func ParseFiatWaterLine(value string) (decimal.Decimal, bool) {
+	if !IsFiatWaterLine(value) {
+		return decimal.Zero, false
+	}
+	usd, err := decimal.NewFromString(strings.TrimPrefix(strings.TrimSpace(value), "$"))
+	if err != nil || !usd.IsPositive() {
+		return decimal.Zero, false
+	}
+	return usd, true
+}
+
+// validateWaterLines checks that every waterLine set on chain parses in the
+// chain type's native units, or in dollars when a price feed is configured
+// and the chain has a native symbol.
+func validateWaterLines(chain *RawChainConfig, priced bool) error {
+	decimals := NativeDecimals(chain.Type)
+	check := func(field, value string) error {
+		if !IsFiatWaterLine(value) {
+			if _, ok := ParseNativeWaterLine(value, decimals); !ok {
+				return fmt.Errorf("chain %s %s %q Not Number", chain.Name, field, value)
+			}
+			return nil
+		}
+		if _, ok := ParseFiatWaterLine(value); !ok {
+			return fmt.Errorf("chain %s %s %q Not Number", chain.Name, field, value)
+		}
+		if !priced {
+			return fmt.Errorf("chain %s %s %q needs a price feed", chain.Name, field, value)
+		}
+		if NativeSymbol(chain.Type, chain.Opts) == "" {
+			return fmt.Errorf("chain %s %s %q needs opts.symbol", chain.Name, field, value)
+		}
+		return nil
+	}
+	if value := chain.Opts[WaterLine]; value != "" {
+		if err := check("opts.waterLine", value); err != nil {
+			return err
+		}
+	}
+	for _, user := range chain.Users {
+		if err := check(fmt.Sprintf("users[%s].waterLine", user.Group), user.WaterLine); err != nil {
+			return err
+		}
+	}
+	return nil
+}
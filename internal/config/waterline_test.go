@@ -57,3 +57,96 @@ func TestParseNativeWaterLine_Invalid(t *testing.T) {
 		})
 	}
 }
+
+func TestParseNativeWaterLine_PerChainType(t *testing.T) {
+	tests := []struct {
+		chainType string
+		value     string
+		want      string
+	}{
+		{chainType: Tron, value: "5.5", want: "5500000"},
+		{chainType: Tron, value: "100", want: "100000000"},
+		{chainType: Xrp, value: "20", want: "20000000"},
+		{chainType: Sol, value: "0.5", want: "500000000"},
+		{chainType: Btc, value: "0.05", want: "5000000"},
+		{chainType: "ethereum", value: "5.5", want: "5500000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chainType+"/"+tt.value, func(t *testing.T) {
+			got, ok := ParseNativeWaterLine(tt.value, NativeDecimals(tt.chainType))
+			if !ok {
+				t.Fatalf("ParseNativeWaterLine(%q) on %s returned ok=false", tt.value, tt.chainType)
+			}
+			if got.String() != tt.want {
+				t.Fatalf("ParseNativeWaterLine(%q) on %s=%s, want %s", tt.value, tt.chainType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_WaterLines(t *testing.T) {
+	build := func(chain RawChainConfig) *Config {
+		chain.Name, chain.Endpoint = "chain", "http://chain"
+		return &Config{Chains: []RawChainConfig{
+			{Name: "map", Endpoint: "http://map"},
+			chain,
+		}}
+	}
+
+	if err := build(RawChainConfig{Type: Tron, Opts: map[string]string{WaterLine: "5.5"}}).validate(); err != nil {
+		t.Fatalf("valid tron waterLine rejected: %v", err)
+	}
+	if err := build(RawChainConfig{Type: Xrp, Users: []From{{Group: "g", From: "r1", WaterLine: "20"}}}).validate(); err != nil {
+		t.Fatalf("valid xrp user waterLine rejected: %v", err)
+	}
+
+	invalid := []RawChainConfig{
+		{Type: Tron, Opts: map[string]string{WaterLine: "abc"}},
+		{Type: Xrp, Opts: map[string]string{WaterLine: "0.0000001"}},
+		{Type: Sol, Users: []From{{Group: "g", From: "s1", WaterLine: "-1"}}},
+		{Type: Btc, Users: []From{{Group: "g", From: "b1"}}},
+	}
+	for _, chain := range invalid {
+		if err := build(chain).validate(); err == nil {
+			t.Fatalf("expected validate to reject %s waterLine %v / %v", chain.Type, chain.Opts, chain.Users)
+		}
+	}
+}
+
+func TestValidate_FiatWaterLines(t *testing.T) {
+	build := func(chain RawChainConfig, price *Price) *Config {
+		chain.Name, chain.Endpoint = "chain", "http://chain"
+		return &Config{Price: price, Chains: []RawChainConfig{
+			{Name: "map", Endpoint: "http://map"},
+			chain,
+		}}
+	}
+	feed := &Price{Url: "http://price/{symbol}"}
+
+	if err := build(RawChainConfig{Type: Tron, Opts: map[string]string{WaterLine: "$500"}}, feed).validate(); err != nil {
+		t.Fatalf("valid tron dollar waterLine rejected: %v", err)
+	}
+	if err := build(RawChainConfig{Opts: map[string]string{WaterLine: "$500", Symbol: "bnb"}}, feed).validate(); err != nil {
+		t.Fatalf("valid evm dollar waterLine rejected: %v", err)
+	}
+
+	invalid := []struct {
+		name  string
+		chain RawChainConfig
+		price *Price
+	}{
+		{name: "no feed", chain: RawChainConfig{Type: Tron, Opts: map[string]string{WaterLine: "$500"}}},
+		{name: "no evm symbol", chain: RawChainConfig{Opts: map[string]string{WaterLine: "$500"}}, price: feed},
+		{name: "malformed", chain: RawChainConfig{Type: Sol, Users: []From{{Group: "g", From: "s1", WaterLine: "$-5"}}}, price: feed},
+		{name: "feed without url", chain: RawChainConfig{Type: Sol}, price: &Price{}},
+	}
+	for _, tt := range invalid {
+		if err := build(tt.chain, tt.price).validate(); err == nil {
+			t.Fatalf("%s: expected validate to fail", tt.name)
+		}
+	}
+	if got := NativeSymbol("ethereum", map[string]string{Symbol: "bnb"}); got != "BNB" {
+		t.Fatalf("NativeSymbol = %q, want BNB", got)
+	}
+}
@@ -0,0 +1,133 @@
+// Package amount is the exact token quantity shared by the chain monitors.
+package amount
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+// Amount is an integer count of a token's smallest unit together with the
+// number of decimals in one whole token. The zero value is 0 with no
+// decimals.
+type Amount struct {
+	raw      *big.Int
+	decimals int32
+}
+
+// New returns raw smallest units of a token with decimals. raw is copied; nil
+// is zero.
+func New(raw *big.Int, decimals int32) Amount {
+	if raw == nil {
+		return Amount{raw: new(big.Int), decimals: decimals}
+	}
+	return Amount{raw: new(big.Int).Set(raw), decimals: decimals}
+}
+
+// FromInt64 returns raw smallest units of a token with decimals.
+func FromInt64(raw int64, decimals int32) Amount {
+	return Amount{raw: big.NewInt(raw), decimals: decimals}
+}
+
+// FromUint64 returns raw smallest units of a token with decimals.
+func FromUint64(raw uint64, decimals int32) Amount {
+	return Amount{raw: new(big.Int).SetUint64(raw), decimals: decimals}
+}
+
+// FromRawString parses a base-10 count of smallest units.
+func FromRawString(raw string, decimals int32) (Amount, error) {
+	value, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("amount %q is not an integer", raw)
+	}
+	return Amount{raw: value, decimals: decimals}, nil
+}
+
+// Parse reads value in whole-token units, e.g. "5.5". It fails when value
+// has more fractional digits than decimals can hold.
+func Parse(value string, decimals int32) (Amount, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return Amount{}, fmt.Errorf("amount %q Not Number", value)
+	}
+	return fromDecimal(d, decimals, value)
+}
+
+// FromFloat converts a float configured in whole-token units, using its
+// shortest decimal representation so 0.1 stays exactly 0.1.
+func FromFloat(value float64, decimals int32) (Amount, error) {
+	return fromDecimal(decimal.NewFromFloat(value), decimals, fmt.Sprint(value))
+}
+
+func fromDecimal(d decimal.Decimal, decimals int32, value string) (Amount, error) {
+	if decimals < 0 {
+		return Amount{}, fmt.Errorf("amount decimals must not be negative, got %d", decimals)
+	}
+	scaled := d.Shift(decimals)
+	if !scaled.Equal(scaled.Truncate(0)) {
+		return Amount{}, fmt.Errorf("amount %q has more than %d decimals", value, decimals)
+	}
+	return Amount{raw: scaled.BigInt(), decimals: decimals}, nil
+}
+
+// Raw returns a copy of the smallest-unit count.
+func (a Amount) Raw() *big.Int {
+	if a.raw == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(a.raw)
+}
+
+// Decimals returns the number of decimals in one whole token.
+func (a Amount) Decimals() int32 {
+	return a.decimals
+}
+
+// Decimal returns a in whole-token units.
+func (a Amount) Decimal() decimal.Decimal {
+	return decimal.NewFromBigInt(a.Raw(), -a.decimals)
+}
+
+// Cmp compares a and b by value, even when their decimals differ.
+func (a Amount) Cmp(b Amount) int {
+	return a.Decimal().Cmp(b.Decimal())
+}
+
+func (a Amount) LessThan(b Amount) bool {
+	return a.Cmp(b) < 0
+}
+
+func (a Amount) IsZero() bool {
+	return a.raw == nil || a.raw.Sign() == 0
+}
+
+// Add returns a+b in the larger of their decimals.
+func (a Amount) Add(b Amount) Amount {
+	decimals := a.decimals
+	if b.decimals > decimals {
+		decimals = b.decimals
+	}
+	raw := new(big.Int).Add(a.rescale(decimals), b.rescale(decimals))
+	return Amount{raw: raw, decimals: decimals}
+}
+
+// Sub returns a-b in the larger of their decimals.
+func (a Amount) Sub(b Amount) Amount {
+	return a.Add(Amount{raw: new(big.Int).Neg(b.Raw()), decimals: b.decimals})
+}
+
+func (a Amount) rescale(decimals int32) *big.Int {
+	raw := a.Raw()
+	if decimals == a.decimals {
+		return raw
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals-a.decimals)), nil)
+	return raw.Mul(raw, scale)
+}
+
+// String formats a exactly in whole-token units without trailing zeros,
+// e.g. "1234.5".
+func (a Amount) String() string {
+	return a.Decimal().String()
+}
@@ -0,0 +1,88 @@
+package amount
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals int32
+		raw      string
+		str      string
+	}{
+		{value: "5.5", decimals: 6, raw: "5500000", str: "5.5"},
+		{value: "0.000000000000000001", decimals: 18, raw: "1", str: "0.000000000000000001"},
+		{value: "123456789012345678901234.5", decimals: 24, raw: "123456789012345678901234500000000000000000000000", str: "123456789012345678901234.5"},
+		{value: "100", decimals: 0, raw: "100", str: "100"},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.value, tt.decimals)
+		if err != nil {
+			t.Fatalf("Parse(%q, %d) error: %v", tt.value, tt.decimals, err)
+		}
+		if got.Raw().String() != tt.raw {
+			t.Fatalf("Parse(%q, %d).Raw() = %s, want %s", tt.value, tt.decimals, got.Raw(), tt.raw)
+		}
+		if got.String() != tt.str {
+			t.Fatalf("Parse(%q, %d).String() = %s, want %s", tt.value, tt.decimals, got, tt.str)
+		}
+	}
+
+	for _, bad := range []string{"", "abc", "1.0000001"} {
+		if _, err := Parse(bad, 6); err == nil {
+			t.Fatalf("Parse(%q, 6) expected error", bad)
+		}
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	got, err := FromFloat(0.1, 18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Raw().String() != "100000000000000000" {
+		t.Fatalf("FromFloat(0.1, 18) = %s, want exactly 1e17", got.Raw())
+	}
+}
+
+func TestCmpAcrossDecimals(t *testing.T) {
+	trx := FromInt64(5500000, 6)
+	if trx.Cmp(FromInt64(5, 0)) <= 0 {
+		t.Fatal("5.5 TRX must be more than 5")
+	}
+	if !trx.LessThan(FromInt64(6, 0)) {
+		t.Fatal("5.5 TRX must be less than 6")
+	}
+	if trx.Cmp(FromInt64(55, 1)) != 0 {
+		t.Fatal("5500000e-6 must equal 55e-1")
+	}
+
+	// 2^64 wei differ by one unit: float64 can not tell them apart.
+	big1, _ := new(big.Int).SetString("18446744073709551616", 10)
+	big2 := new(big.Int).Add(big1, big.NewInt(1))
+	if !New(big1, 18).LessThan(New(big2, 18)) {
+		t.Fatal("one wei difference lost")
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	sum := FromInt64(15, 1).Add(FromInt64(250, 3))
+	if sum.String() != "1.75" || sum.Decimals() != 3 {
+		t.Fatalf("1.5 + 0.25 = %s (decimals %d), want 1.75 (3)", sum, sum.Decimals())
+	}
+	if diff := FromInt64(1, 0).Sub(FromInt64(25, 1)); diff.String() != "-1.5" {
+		t.Fatalf("1 - 2.5 = %s, want -1.5", diff)
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	var zero Amount
+	if !zero.IsZero() || zero.String() != "0" || zero.Raw().Sign() != 0 {
+		t.Fatalf("zero value = %s", zero)
+	}
+	if New(nil, 18).Cmp(zero) != 0 {
+		t.Fatal("New(nil) must equal the zero value")
+	}
+}
@@ -0,0 +1,159 @@
+package blockstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/mapprotocol/monitor/internal/config"
+)
+
+// TxStatus is one status a tracked transaction was seen in.
+type TxStatus struct {
+	Status    int    `json:"status"`
+	StatusStr string `json:"statusStr"`
+	Time      int64  `json:"time"` // unix seconds the status was first seen
+}
+
+// TxRecord is the tracked state of one cross-chain transaction.
+type TxRecord struct {
+	Hash      string     `json:"hash"`
+	Route     string     `json:"route"`
+	Bridge    string     `json:"bridge"`
+	SrcTime   int64      `json:"srcTime,omitempty"` // unix seconds of the source transaction
+	FirstSeen int64      `json:"firstSeen"`
+	Completed bool       `json:"completed,omitempty"`
+	Overdue   bool       `json:"overdue,omitempty"` // the overdue alarm has been raised
+	GaveUp    bool       `json:"gaveUp,omitempty"`  // Completed because it was tracked too long or its route was removed
+	History   []TxStatus `json:"history"`
+}
+
+// Last returns the latest status of r, or false when none was recorded.
+func (r TxRecord) Last() (TxStatus, bool) {
+	if len(r.History) == 0 {
+		return TxStatus{}, false
+	}
+	return r.History[len(r.History)-1], true
+}
+
+// TxStore keeps TxRecords of a chain/relayer pair in one JSON file next to
+// its block files, so tracked transactions survive restarts.
+type TxStore struct {
+	path     string
+	fullPath string
+	mu       sync.Mutex
+	txs      map[string]*TxRecord
+}
+
+// NewTxStore opens the store of the chain/relayer pair under path, loading
+// the records saved before. An empty path uses PathPostfix.
+func NewTxStore(path string, chain config.ChainId, relayer string) (*TxStore, error) {
+	if path == "" {
+		path = PathPostfix
+	}
+	s := &TxStore{
+		path:     path,
+		fullPath: filepath.Join(path, fmt.Sprintf("%s-%d.txs.json", relayer, chain)),
+		txs:      make(map[string]*TxRecord),
+	}
+	dat, err := os.ReadFile(s.fullPath)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []*TxRecord
+	if err := json.Unmarshal(dat, &records); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", s.fullPath, err)
+	}
+	for _, r := range records {
+		s.txs[r.Hash] = r
+	}
+	return s, nil
+}
+
+// Get returns a copy of the record of hash.
+func (s *TxStore) Get(hash string) (TxRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.txs[hash]
+	if !ok {
+		return TxRecord{}, false
+	}
+	return r.copy(), true
+}
+
+// Put replaces the record of r.Hash.
+func (s *TxStore) Put(r TxRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := r.copy()
+	s.txs[r.Hash] = &c
+}
+
+// Pending returns the records not completed yet, oldest first.
+func (s *TxStore) Pending() []TxRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []TxRecord
+	for _, r := range s.txs {
+		if !r.Completed {
+			out = append(out, r.copy())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FirstSeen != out[j].FirstSeen {
+			return out[i].FirstSeen < out[j].FirstSeen
+		}
+		return out[i].Hash < out[j].Hash
+	})
+	return out
+}
+
+// Prune drops the completed records first seen before the unix time before,
+// returning how many were dropped.
+func (s *TxStore) Prune(before int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for hash, r := range s.txs {
+		if r.Completed && r.FirstSeen < before {
+			delete(s.txs, hash)
+			n++
+		}
+	}
+	return n
+}
+
+// Save writes the records to disk, replacing the file atomically.
+func (s *TxStore) Save() error {
+	s.mu.Lock()
+	records := make([]*TxRecord, 0, len(s.txs))
+	for _, r := range s.txs {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Hash < records[j].Hash })
+	dat, err := json.MarshalIndent(records, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.path, os.ModePerm); err != nil {
+		return err
+	}
+	tmp := s.fullPath + ".tmp"
+	if err := os.WriteFile(tmp, dat, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.fullPath)
+}
+
+func (r TxRecord) copy() TxRecord {
+	r.History = append([]TxStatus(nil), r.History...)
+	return r
+}
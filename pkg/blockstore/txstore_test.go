@@ -0,0 +1,53 @@
+package blockstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTxStore_SaveLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+	s, err := NewTxStore(dir, 22776, "map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Put(TxRecord{Hash: "a", Route: "btc", FirstSeen: 10, History: []TxStatus{{Status: 1, Time: 10}}})
+	s.Put(TxRecord{Hash: "b", Route: "btc", FirstSeen: 5, Completed: true})
+	s.Put(TxRecord{Hash: "c", Route: "btc", FirstSeen: 20})
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewTxStore(dir, 22776, "map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, ok := loaded.Get("a")
+	if !ok || rec.Route != "btc" || len(rec.History) != 1 {
+		t.Fatalf("loaded record = %+v %v", rec, ok)
+	}
+	if last, _ := rec.Last(); last.Status != 1 {
+		t.Fatalf("last status = %+v", last)
+	}
+	pending := loaded.Pending()
+	if len(pending) != 2 || pending[0].Hash != "a" || pending[1].Hash != "c" {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if n := loaded.Prune(100); n != 1 {
+		t.Fatalf("pruned %d, want the completed record only", n)
+	}
+	if _, ok := loaded.Get("b"); ok {
+		t.Fatal("completed record survived prune")
+	}
+}
+
+func TestTxStore_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "map-22776.txs.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTxStore(dir, 22776, "map"); err == nil {
+		t.Fatal("expected an error for a corrupt store")
+	}
+}
@@ -0,0 +1,219 @@
+// Package maintainer probes the status endpoints TSS maintainer nodes serve
+// on their p2p address.
+package maintainer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPort        = 6040
+	DefaultTimeout     = 10 * time.Second
+	DefaultConcurrency = 8
+)
+
+// Options configures a Client. The zero value probes http://<ip>:6040 with a
+// 10 second timeout per request.
+type Options struct {
+	Port        int           // defaults to DefaultPort
+	Scheme      string        // "http" (default) or "https"
+	CaFile      string        // optional PEM bundle trusted for https, system roots otherwise
+	Timeout     time.Duration // per request, defaults to DefaultTimeout
+	Concurrency int           // maintainers probed at once, defaults to DefaultConcurrency
+}
+
+type Client struct {
+	port        int
+	scheme      string
+	httpClient  *http.Client
+	concurrency int
+}
+
+// New builds a client from opts. It fails on an unknown scheme or a CA file
+// that can not be read.
+func New(opts Options) (*Client, error) {
+	scheme := strings.ToLower(opts.Scheme)
+	if scheme == "" {
+		scheme = "http"
+	}
+	if scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("maintainer probe scheme must be http or https, got %q", opts.Scheme)
+	}
+	port := opts.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("maintainer probe port %d out of range", port)
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.CaFile != "" {
+		pem, err := os.ReadFile(opts.CaFile)
+		if err != nil {
+			return nil, fmt.Errorf("read maintainer probe ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in maintainer probe ca file %s", opts.CaFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &Client{
+		port:        port,
+		scheme:      scheme,
+		httpClient:  &http.Client{Timeout: timeout, Transport: transport},
+		concurrency: concurrency,
+	}, nil
+}
+
+type P2PStatusPeer struct {
+	Address        string `json:"address"`
+	IP             string `json:"ip"`
+	Status         string `json:"status"`
+	StoredPeerID   string `json:"stored_peer_id"`
+	NodesPeerID    string `json:"nodes_peer_id"`
+	ReturnedPeerID string `json:"returned_peer_id"`
+	P2PPortOpen    bool   `json:"p2p_port_open"`
+	P2PDialMs      int    `json:"p2p_dial_ms"`
+}
+
+// P2PStatusResponse represents the response from /status/p2p endpoint
+type P2PStatusResponse struct {
+	Peers     []P2PStatusPeer `json:"peers"`
+	PeerCount int             `json:"peer_count"`
+	Errors    interface{}     `json:"errors"`
+}
+
+type ScannerStatus struct {
+	Chain              string `json:"chain"`
+	ChainHeight        int64  `json:"chain_height"`
+	BlockScannerHeight int64  `json:"block_scanner_height"`
+	ScannerHeightDiff  int64  `json:"scanner_height_diff"`
+}
+
+// ScannerStatusResponse represents the response from /status/scanner endpoint
+type ScannerStatusResponse map[string]ScannerStatus
+
+// Result is what one probe of a maintainer returned. A nil error means the
+// endpoint answered 200 with a body that decoded.
+type Result struct {
+	Host       string
+	PingErr    error
+	P2P        *P2PStatusResponse
+	P2PErr     error
+	Scanner    ScannerStatusResponse
+	ScannerErr error
+}
+
+// Probe queries /ping, /status/p2p and /status/scanner of every host,
+// at most Concurrency hosts at a time, and returns the results in the order
+// of hosts.
+func (c *Client) Probe(ctx context.Context, hosts []string) []Result {
+	results := make([]Result, len(hosts))
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = c.probe(ctx, host)
+		}(i, host)
+	}
+	wg.Wait()
+	return results
+}
+
+func (c *Client) probe(ctx context.Context, host string) Result {
+	res := Result{Host: host}
+	res.PingErr = c.Ping(ctx, host)
+	res.P2P, res.P2PErr = c.P2PStatus(ctx, host)
+	res.Scanner, res.ScannerErr = c.ScannerStatus(ctx, host)
+	return res
+}
+
+// Ping checks that host answers /ping with 200.
+func (c *Client) Ping(ctx context.Context, host string) error {
+	_, err := c.get(ctx, host, "/ping")
+	return err
+}
+
+// P2PStatus fetches the P2P status of host.
+func (c *Client) P2PStatus(ctx context.Context, host string) (*P2PStatusResponse, error) {
+	body, err := c.get(ctx, host, "/status/p2p")
+	if err != nil {
+		return nil, err
+	}
+	var status P2PStatusResponse
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	return &status, nil
+}
+
+// ScannerStatus fetches the block scanner status of host.
+func (c *Client) ScannerStatus(ctx context.Context, host string) (ScannerStatusResponse, error) {
+	body, err := c.get(ctx, host, "/status/scanner")
+	if err != nil {
+		return nil, err
+	}
+	var status ScannerStatusResponse
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	return status, nil
+}
+
+// CloseIdleConnections closes the idle connections of c, for a client that
+// is replaced.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// URL returns the address of path on host.
+func (c *Client) URL(host, path string) string {
+	return fmt.Sprintf("%s://%s%s", c.scheme, net.JoinHostPort(host, strconv.Itoa(c.port)), path)
+}
+
+func (c *Client) get(ctx context.Context, host, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL(host, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
+	}
+	return body, nil
+}
@@ -0,0 +1,120 @@
+package maintainer
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func statusHandler(delay time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		_, _ = w.Write([]byte("pong"))
+	})
+	mux.HandleFunc("/status/p2p", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"peers":[{"address":"0x1","ip":"10.0.0.1","status":"online"}],"peer_count":1}`))
+	})
+	mux.HandleFunc("/status/scanner", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	return mux
+}
+
+func hostPort(t *testing.T, rawURL string) (string, int) {
+	t.Helper()
+	host, port, err := net.SplitHostPort(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := strconv.Atoi(port)
+	return host, n
+}
+
+func TestProbe(t *testing.T) {
+	srv := httptest.NewServer(statusHandler(0))
+	defer srv.Close()
+	host, port := hostPort(t, srv.Listener.Addr().String())
+
+	c, err := New(Options{Port: port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := c.Probe(context.Background(), []string{host, host})
+	if len(results) != 2 {
+		t.Fatalf("results = %d", len(results))
+	}
+	res := results[0]
+	if res.PingErr != nil || res.P2PErr != nil || len(res.P2P.Peers) != 1 {
+		t.Fatalf("result = %+v", res)
+	}
+	if res.ScannerErr == nil {
+		t.Fatal("expected a non-200 scanner status to fail")
+	}
+}
+
+func TestProbe_Timeout(t *testing.T) {
+	srv := httptest.NewServer(statusHandler(500 * time.Millisecond))
+	defer srv.Close()
+	host, port := hostPort(t, srv.Listener.Addr().String())
+
+	c, err := New(Options{Port: port, Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	results := c.Probe(context.Background(), []string{host, host, host})
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("probes took %s, expected them to time out concurrently", elapsed)
+	}
+	for _, res := range results {
+		if res.PingErr == nil {
+			t.Fatal("expected the slow ping to time out")
+		}
+	}
+}
+
+func TestProbe_HttpsWithCa(t *testing.T) {
+	srv := httptest.NewTLSServer(statusHandler(0))
+	defer srv.Close()
+	host, port := hostPort(t, srv.Listener.Addr().String())
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(Options{Port: port, Scheme: "https", CaFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Ping(context.Background(), host); err != nil {
+		t.Fatalf("ping over https: %v", err)
+	}
+
+	untrusted, _ := New(Options{Port: port, Scheme: "https"})
+	if err = untrusted.Ping(context.Background(), host); err == nil {
+		t.Fatal("expected an untrusted certificate to fail")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	if _, err := New(Options{Scheme: "ftp"}); err == nil {
+		t.Fatal("expected an unknown scheme to fail")
+	}
+	if _, err := New(Options{Scheme: "https", CaFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Fatal("expected a missing ca file to fail")
+	}
+	c, _ := New(Options{})
+	if got := c.URL("10.0.0.1", "/ping"); got != "http://10.0.0.1:6040/ping" {
+		t.Fatalf("URL = %s", got)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -44,13 +46,7 @@ type AddressInfo struct {
 }
 
 func (c *MempoolClient) ListUnspent(address btcutil.Address) ([]*UnspentOutput, error) {
-	res, err := c.request(http.MethodGet, fmt.Sprintf("/address/%s/utxo", address.EncodeAddress()), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	var utxos UTXOs
-	err = json.Unmarshal(res, &utxos)
+	utxos, err := c.ListUTXOs(address)
 	if err != nil {
 		return nil, err
 	}
@@ -68,18 +64,102 @@ func (c *MempoolClient) ListUnspent(address btcutil.Address) ([]*UnspentOutput,
 	}
 	return unspentOutputs, nil
 }
+
+// ListUTXOs returns the unspent outputs of address as reported by the API,
+// including their confirmation status.
+func (c *MempoolClient) ListUTXOs(address btcutil.Address) (UTXOs, error) {
+	res, err := c.request(http.MethodGet, fmt.Sprintf("/address/%s/utxo", address.EncodeAddress()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var utxos UTXOs
+	err = json.Unmarshal(res, &utxos)
+	if err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+// Balances splits an address balance into its confirmed part and the net
+// effect of transactions still in the mempool.
+type Balances struct {
+	Confirmed int64 // chain_stats funded - spent
+	Pending   int64 // mempool_stats funded - spent, negative for a net outflow
+	Effective int64 // Confirmed + Pending
+}
+
+// PendingOutflow returns the net amount leaving the address in unconfirmed
+// transactions, or 0 when the mempool adds to it.
+func (b *Balances) PendingOutflow() int64 {
+	if b.Pending < 0 {
+		return -b.Pending
+	}
+	return 0
+}
+
+// GetBalance returns the confirmed balance of address.
 func (c *MempoolClient) GetBalance(address btcutil.Address) (int64, error) {
-	res, err := c.request(http.MethodGet, fmt.Sprintf("/address/%s", address.EncodeAddress()), nil)
+	balances, err := c.GetBalances(address)
 	if err != nil {
 		return 0, err
 	}
+	return balances.Confirmed, nil
+}
+
+// GetBalances returns the confirmed, pending and effective balance of address.
+func (c *MempoolClient) GetBalances(address btcutil.Address) (*Balances, error) {
+	res, err := c.request(http.MethodGet, fmt.Sprintf("/address/%s", address.EncodeAddress()), nil)
+	if err != nil {
+		return nil, err
+	}
 
 	var info AddressInfo
 	err = json.Unmarshal(res, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed := info.ChainStats.FundedTxoSum - info.ChainStats.SpentTxoSum
+	pending := info.MempoolStats.FundedTxoSum - info.MempoolStats.SpentTxoSum
+	return &Balances{
+		Confirmed: confirmed,
+		Pending:   pending,
+		Effective: confirmed + pending,
+	}, nil
+}
+
+// TipHeight returns the height of the indexer's best block.
+func (c *MempoolClient) TipHeight() (int64, error) {
+	res, err := c.request(http.MethodGet, "/blocks/tip/height", nil)
 	if err != nil {
 		return 0, err
 	}
+	return strconv.ParseInt(strings.TrimSpace(string(res)), 10, 64)
+}
+
+// AddressTxids returns one page of txids touching address, newest first.
+// An empty lastSeen returns the first page; passing the last txid of a page
+// returns the confirmed transactions that follow it.
+func (c *MempoolClient) AddressTxids(address btcutil.Address, lastSeen string) ([]string, error) {
+	subPath := fmt.Sprintf("/address/%s/txs", address.EncodeAddress())
+	if lastSeen != "" {
+		subPath = fmt.Sprintf("/address/%s/txs/chain/%s", address.EncodeAddress(), lastSeen)
+	}
+	res, err := c.request(http.MethodGet, subPath, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	balance := info.ChainStats.FundedTxoSum - info.ChainStats.SpentTxoSum
-	return balance, nil
+	var txs []struct {
+		Txid string `json:"txid"`
+	}
+	if err := json.Unmarshal(res, &txs); err != nil {
+		return nil, err
+	}
+	txids := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		txids = append(txids, tx.Txid)
+	}
+	return txids, nil
 }
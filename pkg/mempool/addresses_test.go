@@ -12,7 +12,10 @@ import (
 func TestListUnspent(t *testing.T) {
 	// https://mempool.space/signet/api/address/tb1p8lh4np5824u48ppawq3numsm7rss0de4kkxry0z70dcfwwwn2fcspyyhc7/utxo
 	netParams := &chaincfg.SigNetParams
-	client := NewClient(netParams)
+	client, err := NewClient(netParams)
+	if err != nil {
+		t.Fatal(err)
+	}
 	address, _ := btcutil.DecodeAddress("tb1p8lh4np5824u48ppawq3numsm7rss0de4kkxry0z70dcfwwwn2fcspyyhc7", netParams)
 	unspentList, err := client.ListUnspent(address)
 	if err != nil {
@@ -27,7 +30,10 @@ func TestListUnspent(t *testing.T) {
 
 func Test_getBalance(t *testing.T) {
 	netParams := &chaincfg.MainNetParams
-	client := NewClient(netParams)
+	client, err := NewClient(netParams)
+	if err != nil {
+		t.Fatal(err)
+	}
 	address, _ := btcutil.DecodeAddress("bc1pv5lu5aklz64sye9f4zmnjkfg8j6s2tllu3fem4cs9t0hcrnz5e7qy0qw6e", netParams)
 	b, err := client.GetBalance(address)
 	if err != nil {
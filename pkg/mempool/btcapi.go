@@ -1,6 +1,7 @@
 package mempool
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -8,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"io"
 	"net/http"
+	"strings"
 )
 
 type UnspentOutput struct {
@@ -22,15 +24,49 @@ type BTCAPIClient interface {
 	GetBalance(address btcutil.Address) (int64, error)
 }
 
+// StatusError is returned when the API answers with a non-2xx status.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("mempool api returned %d: %s", e.Code, e.Body)
+}
+
 func Request(method, baseURL, subPath string, requestBody io.Reader) ([]byte, error) {
-	url := fmt.Sprintf("%s%s", baseURL, subPath)
-	req, err := http.NewRequest(method, url, requestBody)
+	return RequestWith(http.DefaultClient, method, baseURL, subPath, requestBody)
+}
+
+// RequestWith is Request sent through client, so callers can bound it with a
+// timeout.
+func RequestWith(client *http.Client, method, baseURL, subPath string, requestBody io.Reader) ([]byte, error) {
+	var body []byte
+	if requestBody != nil {
+		var err error
+		body, err = io.ReadAll(requestBody)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read request body")
+		}
+	}
+	return do(client, method, baseURL+subPath, body, "", "")
+}
+
+func do(client *http.Client, method, url string, requestBody []byte, authHeader, authValue string) ([]byte, error) {
+	var reader io.Reader
+	if requestBody != nil {
+		reader = bytes.NewReader(requestBody)
+	}
+	req, err := http.NewRequest(method, url, reader)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create request")
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	if authHeader != "" {
+		req.Header.Set(authHeader, authValue)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to send request")
 	}
@@ -39,5 +75,18 @@ func Request(method, baseURL, subPath string, requestBody io.Reader) ([]byte, er
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to read response body")
 	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
 	return body, nil
 }
+
+// retryable reports whether err may go away on a later attempt: transport
+// failures, rate limiting and server errors are, other statuses are not.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= http.StatusInternalServerError
+}
@@ -1,35 +1,148 @@
 package mempool
 
 import (
+	"fmt"
 	"io"
-	"log"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/wire"
 )
 
+const (
+	DefaultTimeout       = 15 * time.Second
+	DefaultRetryInterval = time.Second
+)
+
+// Options configures a MempoolClient. Only Network is needed to talk to the
+// public mempool.space API; BaseURL points the client at any other
+// Esplora-compatible API, e.g. a self-hosted node or a test stub.
+type Options struct {
+	BaseURL       string
+	Network       *chaincfg.Params // defaults to mainnet
+	Timeout       time.Duration    // per request, defaults to DefaultTimeout
+	Retries       int              // extra attempts on transport errors, 429 and 5xx
+	RetryInterval time.Duration    // defaults to DefaultRetryInterval
+	AuthHeader    string           // optional, e.g. "Authorization"
+	AuthValue     string
+}
+
 type MempoolClient struct {
-	baseURL string
+	baseURL       string
+	netParams     *chaincfg.Params
+	httpClient    *http.Client
+	retries       int
+	retryInterval time.Duration
+	authHeader    string
+	authValue     string
 }
 
-func NewClient(netParams *chaincfg.Params) *MempoolClient {
-	baseURL := ""
-	if netParams.Net == wire.MainNet {
-		baseURL = "https://mempool.space/api"
-	} else if netParams.Net == wire.TestNet3 {
-		baseURL = "https://mempool.space/testnet/api"
-	} else if netParams.Net == chaincfg.SigNetParams.Net {
-		baseURL = "https://mempool.space/signet/api"
-	} else {
-		log.Fatal("mempool don't support other netParams")
+// New builds a client from opts. It fails when no BaseURL is given and the
+// network has no public mempool.space API.
+func New(opts Options) (*MempoolClient, error) {
+	netParams := opts.Network
+	if netParams == nil {
+		netParams = &chaincfg.MainNetParams
+	}
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		var err error
+		baseURL, err = DefaultBaseURL(netParams)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Retries < 0 {
+		return nil, fmt.Errorf("mempool retries must not be negative, got %d", opts.Retries)
+	}
+	if opts.AuthValue != "" && opts.AuthHeader == "" {
+		return nil, fmt.Errorf("mempool auth value set without auth header")
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	retryInterval := opts.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = DefaultRetryInterval
 	}
 	return &MempoolClient{
-		baseURL: baseURL,
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		netParams:     netParams,
+		httpClient:    &http.Client{Timeout: timeout},
+		retries:       opts.Retries,
+		retryInterval: retryInterval,
+		authHeader:    opts.AuthHeader,
+		authValue:     opts.AuthValue,
+	}, nil
+}
+
+// NewClient returns a client for the public mempool.space API of netParams.
+func NewClient(netParams *chaincfg.Params) (*MempoolClient, error) {
+	return New(Options{Network: netParams})
+}
+
+// DefaultBaseURL returns the public mempool.space API of netParams.
+func DefaultBaseURL(netParams *chaincfg.Params) (string, error) {
+	switch netParams.Net {
+	case wire.MainNet:
+		return "https://mempool.space/api", nil
+	case wire.TestNet3:
+		return "https://mempool.space/testnet/api", nil
+	case chaincfg.SigNetParams.Net:
+		return "https://mempool.space/signet/api", nil
+	default:
+		return "", fmt.Errorf("mempool.space has no api for network %s, a base url is required", netParams.Name)
+	}
+}
+
+// ParseNetwork maps a configured network name to its chain params. An empty
+// name selects mainnet.
+func ParseNetwork(network string) (*chaincfg.Params, error) {
+	switch strings.ToLower(strings.TrimSpace(network)) {
+	case "", "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet", "testnet3":
+		return &chaincfg.TestNet3Params, nil
+	case "signet":
+		return &chaincfg.SigNetParams, nil
+	default:
+		return nil, fmt.Errorf("unsupported bitcoin network %q", network)
 	}
 }
 
+// NetParams returns the network addresses queried through c belong to.
+func (c *MempoolClient) NetParams() *chaincfg.Params {
+	return c.netParams
+}
+
 func (c *MempoolClient) request(method, subPath string, requestBody io.Reader) ([]byte, error) {
-	return Request(method, c.baseURL, subPath, requestBody)
+	var body []byte
+	if requestBody != nil {
+		var err error
+		body, err = io.ReadAll(requestBody)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		ret []byte
+		err error
+	)
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.retryInterval)
+		}
+		ret, err = do(c.httpClient, method, c.baseURL+subPath, body, c.authHeader, c.authValue)
+		if err == nil || !retryable(err) {
+			return ret, err
+		}
+	}
+	return nil, err
 }
 
 var _ BTCAPIClient = (*MempoolClient)(nil)
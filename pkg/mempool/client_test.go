@@ -0,0 +1,182 @@
+package mempool
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func TestParseNetwork(t *testing.T) {
+	tests := []struct {
+		network string
+		want    *chaincfg.Params
+	}{
+		{network: "", want: &chaincfg.MainNetParams},
+		{network: "mainnet", want: &chaincfg.MainNetParams},
+		{network: "testnet", want: &chaincfg.TestNet3Params},
+		{network: "Signet", want: &chaincfg.SigNetParams},
+	}
+	for _, tt := range tests {
+		got, err := ParseNetwork(tt.network)
+		if err != nil {
+			t.Fatalf("ParseNetwork(%q) error: %v", tt.network, err)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseNetwork(%q) = %s, want %s", tt.network, got.Name, tt.want.Name)
+		}
+	}
+
+	if _, err := ParseNetwork("regtest2"); err == nil {
+		t.Fatal("expected error for unknown network")
+	}
+}
+
+func TestNew_GetBalance(t *testing.T) {
+	const addr = "bc1pv5lu5aklz64sye9f4zmnjkfg8j6s2tllu3fem4cs9t0hcrnz5e7qy0qw6e"
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Api-Key") != "secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req.URL.Path != "/api/address/"+addr {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = rw.Write([]byte(`{"address":"` + addr + `","chain_stats":{"funded_txo_sum":150000,"spent_txo_sum":50000}}`))
+	}))
+	defer server.Close()
+
+	address, err := btcutil.DecodeAddress(addr, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := New(Options{BaseURL: server.URL + "/api/", AuthHeader: "X-Api-Key", AuthValue: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.GetBalance(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 100000 {
+		t.Fatalf("GetBalance = %d, want 100000", got)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	if _, err := New(Options{Network: &chaincfg.RegressionNetParams}); err == nil {
+		t.Fatal("expected error for regtest without base url")
+	}
+	if _, err := New(Options{BaseURL: "http://127.0.0.1", Network: &chaincfg.RegressionNetParams}); err != nil {
+		t.Fatalf("regtest with base url: %v", err)
+	}
+	if _, err := New(Options{Retries: -1}); err == nil {
+		t.Fatal("expected error for negative retries")
+	}
+	if _, err := New(Options{AuthValue: "secret"}); err == nil {
+		t.Fatal("expected error for auth value without header")
+	}
+}
+
+func TestRequest_Retries(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int
+	}{
+		{name: "server error is retried", status: http.StatusBadGateway, wantCalls: 3},
+		{name: "rate limit is retried", status: http.StatusTooManyRequests, wantCalls: 3},
+		{name: "client error is not retried", status: http.StatusBadRequest, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				calls++
+				rw.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client, err := New(Options{BaseURL: server.URL, Retries: 2, RetryInterval: time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = client.TipHeight()
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.Code != tt.status {
+				t.Fatalf("TipHeight error = %v, want status %d", err, tt.status)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRequest_RecoversAfterRetry(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = rw.Write([]byte("840000"))
+	}))
+	defer server.Close()
+
+	client, err := New(Options{BaseURL: server.URL, Retries: 1, RetryInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.TipHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 840000 {
+		t.Fatalf("TipHeight = %d, want 840000", got)
+	}
+}
+
+func TestGetBalances(t *testing.T) {
+	const addr = "bc1pv5lu5aklz64sye9f4zmnjkfg8j6s2tllu3fem4cs9t0hcrnz5e7qy0qw6e"
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(`{"address":"` + addr + `",` +
+			`"chain_stats":{"funded_txo_sum":500000000,"spent_txo_sum":100000000},` +
+			`"mempool_stats":{"funded_txo_sum":20000000,"spent_txo_sum":300000000}}`))
+	}))
+	defer server.Close()
+
+	address, err := btcutil.DecodeAddress(addr, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := New(Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.GetBalances(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Balances{Confirmed: 400000000, Pending: -280000000, Effective: 120000000}
+	if *got != want {
+		t.Fatalf("GetBalances = %+v, want %+v", *got, want)
+	}
+	if got.PendingOutflow() != 280000000 {
+		t.Fatalf("PendingOutflow = %d, want 280000000", got.PendingOutflow())
+	}
+
+	confirmed, err := client.GetBalance(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmed != want.Confirmed {
+		t.Fatalf("GetBalance = %d, want confirmed %d", confirmed, want.Confirmed)
+	}
+}
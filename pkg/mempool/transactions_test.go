@@ -9,7 +9,10 @@ import (
 
 func TestGetRawTransaction(t *testing.T) {
 	//https://mempool.space/signet/api/tx/b752d80e97196582fd02303f76b4b886c222070323fb7ccd425f6c89f5445f6c/hex
-	client := NewClient(&chaincfg.SigNetParams)
+	client, err := NewClient(&chaincfg.SigNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
 	txId, _ := chainhash.NewHashFromStr("b752d80e97196582fd02303f76b4b886c222070323fb7ccd425f6c89f5445f6c")
 	transaction, err := client.GetRawTransaction(txId)
 	if err != nil {
@@ -68,7 +71,10 @@ func TestMempoolClient_TransactionStatus(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := NewClient(tt.fields.net)
+			c, err := NewClient(tt.fields.net)
+			if err != nil {
+				t.Fatal(err)
+			}
 			txId, err := chainhash.NewHashFromStr(tt.args.txHash)
 			if err != nil {
 				t.Fatal(err)
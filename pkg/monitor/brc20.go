@@ -0,0 +1,147 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/util"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	providerGenii = "genii"
+	providerOkx   = "okx"
+)
+
+// brc20Provider reports the BRC-20 balance of tick summed over a comma
+// separated address list, exact to the decimals of the tick.
+type brc20Provider interface {
+	Name() string
+	Balance(addrs, tick string) (decimal.Decimal, error)
+}
+
+type geniiProvider struct {
+	api *config.Api
+}
+
+func (p *geniiProvider) Name() string { return providerGenii }
+
+func (p *geniiProvider) Balance(addrs, tick string) (decimal.Decimal, error) {
+	return GetMulAddBalance(p.api.Endpoint, p.api.Key, addrs, tick)
+}
+
+type okxProvider struct {
+	api *config.OkxApi
+}
+
+func (p *okxProvider) Name() string { return providerOkx }
+
+func (p *okxProvider) Balance(addrs, tick string) (decimal.Decimal, error) {
+	return getBalanceByOk(p.api.Endpoint, p.api.Key, addrs, p.api.Project, p.api.Passphrase, p.api.SecretKey, tick)
+}
+
+// brc20Providers builds the providers named in tk.Providers, in priority
+// order. Without Providers it uses genii, then okx when tk.Okx is set.
+func brc20Providers(tk *config.Token, genni *config.Api) ([]brc20Provider, error) {
+	names := tk.Providers
+	if len(names) == 0 {
+		names = []string{providerGenii}
+		if tk.Okx != nil {
+			names = append(names, providerOkx)
+		}
+	}
+
+	providers := make([]brc20Provider, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case providerGenii:
+			if genni == nil || genni.Endpoint == "" {
+				return nil, errors.New("brc20 provider genii requires genni endpoint")
+			}
+			providers = append(providers, &geniiProvider{api: genni})
+		case providerOkx:
+			if tk.Okx == nil || tk.Okx.Endpoint == "" {
+				return nil, errors.New("brc20 provider okx requires token.okx endpoint")
+			}
+			providers = append(providers, &okxProvider{api: tk.Okx})
+		default:
+			return nil, errors.Errorf("unknown brc20 provider %q", name)
+		}
+	}
+	return providers, nil
+}
+
+type brc20Result struct {
+	Provider string
+	Balance  decimal.Decimal
+	Err      error
+}
+
+// queryBrc20 asks every provider, so disagreements are seen even while the
+// primary one is healthy.
+func queryBrc20(providers []brc20Provider, addrs, tick string) []brc20Result {
+	results := make([]brc20Result, 0, len(providers))
+	for _, p := range providers {
+		bal, err := p.Balance(addrs, tick)
+		results = append(results, brc20Result{Provider: p.Name(), Balance: bal, Err: err})
+	}
+	return results
+}
+
+// pickBrc20 returns the first successful result in priority order.
+func pickBrc20(results []brc20Result) (brc20Result, bool) {
+	for _, r := range results {
+		if r.Err == nil {
+			return r, true
+		}
+	}
+	return brc20Result{}, false
+}
+
+// brc20Disagreement describes the successful results that differ from the
+// picked one by more than tolerance (relative to the picked balance), or
+// returns "" when they agree.
+func brc20Disagreement(picked brc20Result, results []brc20Result, tolerance float64) string {
+	var diffs []string
+	for _, r := range results {
+		if r.Err != nil || r.Provider == picked.Provider {
+			continue
+		}
+		diff := r.Balance.Sub(picked.Balance).Abs()
+		if diff.LessThanOrEqual(picked.Balance.Abs().Mul(decimal.NewFromFloat(tolerance))) {
+			continue
+		}
+		diffs = append(diffs, fmt.Sprintf("%s=%s", r.Provider, r.Balance))
+	}
+	if len(diffs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s=%s %s", picked.Provider, picked.Balance, strings.Join(diffs, " "))
+}
+
+// brc20Balance returns the balance of tick held by addrs from the highest
+// priority provider that answers, and alarms when the providers disagree.
+func (m *Monitor) brc20Balance(addrs, tick string) (decimal.Decimal, error) {
+	providers, err := brc20Providers(m.Cfg.Tk, m.Cfg.Genni)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	results := queryBrc20(providers, addrs, tick)
+	for _, r := range results {
+		if r.Err != nil {
+			m.Log.Error("Check brc20 balance, provider failed", "token", tick, "provider", r.Provider, "err", r.Err)
+		}
+	}
+	picked, ok := pickBrc20(results)
+	if !ok {
+		return decimal.Zero, errors.Errorf("all brc20 providers failed for %s", tick)
+	}
+	if diff := brc20Disagreement(picked, results, m.Cfg.Tk.Tolerance); diff != "" {
+		util.Alarm(context.Background(), fmt.Sprintf("brc20 providers disagree token=%s, tolerance=%v, %s",
+			tick, m.Cfg.Tk.Tolerance, diff))
+	}
+	return picked.Balance, nil
+}
@@ -0,0 +1,121 @@
+package monitor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+func TestBrc20Providers_Order(t *testing.T) {
+	genni := &config.Api{Endpoint: "http://genii"}
+	okx := &config.OkxApi{Endpoint: "http://okx"}
+	tests := []struct {
+		name string
+		tk   config.Token
+		want []string
+	}{
+		{name: "default genii only", tk: config.Token{}, want: []string{providerGenii}},
+		{name: "default genii then okx", tk: config.Token{Okx: okx}, want: []string{providerGenii, providerOkx}},
+		{name: "okx first", tk: config.Token{Okx: okx, Providers: []string{"OKX", "genii"}}, want: []string{providerOkx, providerGenii}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers, err := brc20Providers(&tt.tk, genni)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(providers) != len(tt.want) {
+				t.Fatalf("got %d providers, want %v", len(providers), tt.want)
+			}
+			for i, p := range providers {
+				if p.Name() != tt.want[i] {
+					t.Fatalf("provider %d = %s, want %s", i, p.Name(), tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := brc20Providers(&config.Token{Providers: []string{"okx"}}, genni); err == nil {
+		t.Fatal("expected error for okx without token.okx")
+	}
+	if _, err := brc20Providers(&config.Token{Providers: []string{"unisat"}}, genni); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
+
+func TestPickBrc20_Failover(t *testing.T) {
+	results := []brc20Result{
+		{Provider: providerGenii, Err: errors.New("503")},
+		{Provider: providerOkx, Balance: decimal.NewFromInt(1000)},
+	}
+	picked, ok := pickBrc20(results)
+	if !ok || picked.Provider != providerOkx || !picked.Balance.Equal(decimal.NewFromInt(1000)) {
+		t.Fatalf("pickBrc20 = %+v, %v, want okx 1000", picked, ok)
+	}
+
+	if _, ok := pickBrc20([]brc20Result{{Provider: providerGenii, Err: errors.New("503")}}); ok {
+		t.Fatal("pickBrc20 must fail when every provider failed")
+	}
+}
+
+func TestBrc20Disagreement(t *testing.T) {
+	picked := brc20Result{Provider: providerGenii, Balance: decimal.NewFromInt(100000)}
+	tests := []struct {
+		name      string
+		other     brc20Result
+		tolerance float64
+		want      bool
+	}{
+		{name: "equal", other: brc20Result{Provider: providerOkx, Balance: decimal.NewFromInt(100000)}},
+		{name: "within tolerance", other: brc20Result{Provider: providerOkx, Balance: decimal.NewFromInt(100050)}, tolerance: 0.001},
+		{name: "beyond tolerance", other: brc20Result{Provider: providerOkx, Balance: decimal.NewFromInt(100200)}, tolerance: 0.001, want: true},
+		{name: "exact by default", other: brc20Result{Provider: providerOkx, Balance: decimal.RequireFromString("100000.1")}, want: true},
+		{name: "failed provider ignored", other: brc20Result{Provider: providerOkx, Err: errors.New("timeout")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := brc20Disagreement(picked, []brc20Result{picked, tt.other}, tt.tolerance)
+			if (got != "") != tt.want {
+				t.Fatalf("brc20Disagreement = %q, want disagreement %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBalanceByOk_FallsBackToNextKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Ok-Access-Sign") == "" || req.Header.Get("OK-ACCESS-TIMESTAMP") == "" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req.Header.Get("OK-ACCESS-KEY") == "k1" {
+			_, _ = rw.Write([]byte(`{"code":"50011","msg":"Too Many Requests","data":[]}`))
+			return
+		}
+		balance := "1500.5"
+		if req.URL.Query().Get("address") == "bc1qb" {
+			balance = "250"
+		}
+		_, _ = rw.Write([]byte(`{"code":"0","msg":"","data":[{"balanceList":[{"token":"ordi","balance":"` + balance + `"}]}]}`))
+	}))
+	defer server.Close()
+
+	got, err := getBalanceByOk(server.URL, "k1,k2", "bc1qa,bc1qb", "p", "pp1,pp2", "s1,s2", "ordi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(decimal.RequireFromString("1750.5")) {
+		t.Fatalf("getBalanceByOk = %s, want 1750.5", got)
+	}
+
+	if _, err := getBalanceByOk(server.URL, "k1", "bc1qa", "p", "pp1", "s1", "ordi"); err == nil {
+		t.Fatal("expected error when every key fails")
+	}
+	if _, err := getBalanceByOk(server.URL, "k1,k2", "bc1qa", "p", "pp1", "s1,s2", "ordi"); err == nil {
+		t.Fatal("expected error for mismatched credential lists")
+	}
+}
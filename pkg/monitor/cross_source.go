@@ -0,0 +1,209 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/cockroachdb/errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/gagliardetto/solana-go"
+	solrpc "github.com/gagliardetto/solana-go/rpc"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/mempool"
+)
+
+const defaultCrossTxBlocks = 1000
+
+// crossTx is an outbound bridge transaction found on a source chain. Time
+// is its unix time on that chain, 0 when the chain does not say.
+type crossTx struct {
+	Hash string
+	Time int64
+}
+
+// crossTxSource discovers the most recent outbound bridge transactions of
+// one route, newest first.
+type crossTxSource interface {
+	recentTxs(ctx context.Context, limit int) ([]crossTx, error)
+}
+
+// newCrossTxSource builds the discovery client of src.
+func newCrossTxSource(src config.CrossTxSource) (crossTxSource, error) {
+	switch src.Type {
+	case config.Tron:
+		return &tronSource{endpoint: strings.TrimRight(src.Endpoint, "/"), bridge: src.Bridge,
+			client: &http.Client{Timeout: crossTxHTTPTimeoutSS}}, nil
+	case config.Sol:
+		program, err := solana.PublicKeyFromBase58(src.Bridge)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sol bridge %s", src.Bridge)
+		}
+		return &solSource{client: solrpc.New(src.Endpoint), program: program}, nil
+	default:
+		if !common.IsHexAddress(src.Bridge) {
+			return nil, errors.Errorf("evm bridge %q is not an address", src.Bridge)
+		}
+		client, err := ethclient.Dial(src.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		blocks := src.Blocks
+		if blocks == 0 {
+			blocks = defaultCrossTxBlocks
+		}
+		return &evmSource{client: client, bridge: common.HexToAddress(src.Bridge), topics: config.TopicHashes(src.Topics),
+			blocks: blocks}, nil
+	}
+}
+
+type evmSource struct {
+	client *ethclient.Client
+	bridge common.Address
+	topics []common.Hash
+	blocks uint64
+}
+
+// Close closes the RPC client of s.
+func (s *evmSource) Close() {
+	s.client.Close()
+}
+
+// recentTxs returns the transactions that emitted the tracked events of the
+// bridge in the last blocks blocks.
+func (s *evmSource) recentTxs(ctx context.Context, limit int) ([]crossTx, error) {
+	head, err := s.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from := uint64(0)
+	if head > s.blocks {
+		from = head - s.blocks
+	}
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(head),
+		Addresses: []common.Address{s.bridge},
+	}
+	if len(s.topics) > 0 {
+		query.Topics = [][]common.Hash{s.topics}
+	}
+	logs, err := s.client.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// newest first, one entry per transaction
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].BlockNumber > logs[j].BlockNumber })
+	seen := make(map[common.Hash]bool)
+	var txs []crossTx
+	for _, l := range logs {
+		if seen[l.TxHash] || l.Removed {
+			continue
+		}
+		seen[l.TxHash] = true
+		txs = append(txs, crossTx{Hash: l.TxHash.Hex(), Time: int64(l.BlockTimestamp)})
+		if len(txs) >= limit {
+			break
+		}
+	}
+	return txs, nil
+}
+
+type tronSource struct {
+	endpoint string
+	bridge   string
+	client   *http.Client
+}
+
+// recentTxs lists the confirmed transactions sent to the bridge through the
+// TronGrid v1 API.
+func (s *tronSource) recentTxs(ctx context.Context, limit int) ([]crossTx, error) {
+	u := fmt.Sprintf("%s/v1/accounts/%s/transactions?only_to=true&only_confirmed=true&limit=%d",
+		s.endpoint, url.PathEscape(s.bridge), limit)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("trongrid returned %d", resp.StatusCode)
+	}
+	var body struct {
+		Data []struct {
+			TxID           string `json:"txID"`
+			BlockTimestamp int64  `json:"block_timestamp"` // milliseconds
+			Ret            []struct {
+				ContractRet string `json:"contractRet"`
+			} `json:"ret"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	txs := make([]crossTx, 0, len(body.Data))
+	for _, tx := range body.Data {
+		if len(tx.Ret) > 0 && tx.Ret[0].ContractRet != "" && tx.Ret[0].ContractRet != "SUCCESS" {
+			continue // reverted, nothing was bridged
+		}
+		txs = append(txs, crossTx{Hash: tx.TxID, Time: tx.BlockTimestamp / 1000})
+	}
+	return txs, nil
+}
+
+type solSource struct {
+	client  *solrpc.Client
+	program solana.PublicKey
+}
+
+// recentTxs lists the successful signatures of the bridge program.
+func (s *solSource) recentTxs(ctx context.Context, limit int) ([]crossTx, error) {
+	sigs, err := s.client.GetSignaturesForAddressWithOpts(ctx, s.program, &solrpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Commitment: solrpc.CommitmentFinalized,
+	})
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]crossTx, 0, len(sigs))
+	for _, sig := range sigs {
+		if sig.Err != nil {
+			continue
+		}
+		tx := crossTx{Hash: sig.Signature.String()}
+		if sig.BlockTime != nil {
+			tx.Time = int64(*sig.BlockTime)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// btcSource lists the transactions of the TSS BTC address through Esplora.
+type btcSource struct {
+	client  *mempool.MempoolClient
+	address btcutil.Address
+}
+
+func (s *btcSource) recentTxs(_ context.Context, limit int) ([]crossTx, error) {
+	txids, err := fetchRecentTxids(s.client, s.address, limit)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]crossTx, 0, len(txids))
+	for _, txid := range txids {
+		txs = append(txs, crossTx{Hash: txid})
+	}
+	return txs, nil
+}
@@ -9,20 +9,23 @@ import (
 	"strings"
 	"time"
 
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/blockstore"
+	"github.com/mapprotocol/monitor/pkg/mempool"
+	"github.com/mapprotocol/monitor/pkg/status"
 	"github.com/mapprotocol/monitor/pkg/util"
 )
 
 const (
-	crossTxStatusOk      = 3
-	crossTxDefaultLimit  = 50
 	crossTxHTTPTimeoutSS = 15 * time.Second
-	crossTxStaleAfter    = 2 * time.Hour
+	crossTxRetention     = 7 * 24 * time.Hour // how long completed txs stay in the tx store
+	// crossTxTrackSlas is how many SLAs of its route a transfer is followed
+	// for before it is given up on, so transfers the TSS API never completes
+	// (BTC deposits, unknown txs) do not pile up in the tx store.
+	crossTxTrackSlas = 12
 )
 
-type blockstreamTx struct {
-	Txid string `json:"txid"`
-}
-
 type crossTxResponse struct {
 	Data struct {
 		Data struct {
@@ -35,103 +38,363 @@ type crossTxResponse struct {
 	} `json:"data"`
 }
 
-// crossTxCheck pulls the most recent transactions for the configured BTC
-// address from blockstream.info and reports any whose TSS cross-chain status
-// is not completed (status != 3).
+// crossTxRoute is one source of outbound bridge transactions and the SLA
+// its transfers must complete in.
+type crossTxRoute struct {
+	Name   string
+	Bridge string
+	Source crossTxSource
+	Limit  int
+	Sla    time.Duration
+}
+
+// crossTxCheck discovers the recent outbound bridge transactions of every
+// route (the TSS BTC address and Tss.CrossTxSources) and follows their TSS
+// cross-chain status in the tx store until completed (Tss.StatusOk) or in a
+// final status. Only transitions alarm: a transfer passing the deadline of
+// its status (the route's SLA unless Tss.Statuses says otherwise), a status
+// change of an overdue transfer, an overdue transfer completing, and a
+// transfer given up on after crossTxTrackSlas SLAs.
 func (m *Monitor) crossTxCheck() {
 	if m.Cfg.Tss == nil {
 		return
 	}
-	addr := m.Cfg.Tss.BtcAddress
-	blockstreamURL := strings.TrimRight(m.Cfg.Tss.BlockstreamUrl, "/")
 	tssApiURL := strings.TrimRight(m.Cfg.Tss.TssApiUrl, "/")
-	if addr == "" || blockstreamURL == "" || tssApiURL == "" {
+	if tssApiURL == "" {
 		return
 	}
-
-	limit := m.Cfg.Tss.CrossTxLimit
-	if limit <= 0 {
-		limit = crossTxDefaultLimit
-	}
-
-	client := &http.Client{Timeout: crossTxHTTPTimeoutSS}
-	txids, err := fetchRecentTxids(client, blockstreamURL, addr, limit)
+	store, err := m.crossTxStore(m.Cfg.Tss)
 	if err != nil {
-		m.Log.Error("crossTxCheck fetch txids failed", "addr", addr, "err", err)
+		m.Log.Error("crossTxCheck open tx store failed", "path", m.Cfg.Tss.StorePath, "err", err)
 		return
 	}
-	m.Log.Info("crossTxCheck fetched txids", "addr", addr, "count", len(txids))
-
+	client := &http.Client{Timeout: crossTxHTTPTimeoutSS}
 	now := time.Now().Unix()
-	for _, txid := range txids {
-		status, statusStr, srcTs, err := fetchCrossTxStatus(client, tssApiURL, txid)
+	if n := crossTxOrphans(store, crossTxRouteNames(m.Cfg.Tss)); n > 0 {
+		m.Log.Info("crossTxCheck gave up txs of removed routes", "count", n)
+	}
+	for _, route := range m.crossTxRoutes(m.Cfg.Tss) {
+		txs, err := route.Source.recentTxs(context.Background(), route.Limit)
 		if err != nil {
-			m.Log.Error("crossTxCheck query tss-api failed", "tx", txid, "err", err)
+			m.Log.Error("crossTxCheck fetch txs failed", "route", route.Name, "bridge", route.Bridge, "err", err)
 			continue
 		}
-		if status == crossTxStatusOk {
+		txs = crossTxTracked(store, route.Name, txs)
+		m.Log.Info("crossTxCheck fetched txs", "route", route.Name, "bridge", route.Bridge, "count", len(txs))
+
+		maxAge := crossTxTrackSlas * route.Sla
+		for _, tx := range txs {
+			rec, tracked := store.Get(tx.Hash)
+			if tracked {
+				if alarm := crossTxGiveUp(&rec, now, maxAge); alarm != "" {
+					store.Put(rec)
+					util.Alarm(context.Background(), alarm)
+					continue
+				}
+			}
+			status, statusStr, srcTs, err := fetchCrossTxStatus(client, tssApiURL, tx.Hash)
+			if err != nil {
+				m.Log.Error("crossTxCheck query tss-api failed", "route", route.Name, "tx", tx.Hash, "err", err)
+				continue
+			}
+			if !tracked {
+				rec = blockstore.TxRecord{Hash: tx.Hash, Route: route.Name, Bridge: route.Bridge, FirstSeen: now}
+			}
+			if srcTs == 0 {
+				srcTs = tx.Time
+			}
+			ev := crossTxUpdate(&rec, status, statusStr, srcTs, now, route.Sla, m.Cfg.Tss)
+			if !tracked && !rec.Completed && crossTxGiveUp(&rec, now, maxAge) != "" {
+				// already too old when first seen, e.g. again after being pruned
+				m.Log.Info("crossTxCheck tx too old to track", "route", route.Name, "tx", tx.Hash,
+					"status", status, "status_str", statusStr, "age_seconds", ev.Age)
+				ev.Alarm = ""
+			}
+			store.Put(rec)
+			switch {
+			case ev.Alarm != "":
+				m.Log.Warn("crossTxCheck status abnormal", "route", route.Name, "tx", tx.Hash,
+					"status", status, "status_str", statusStr, "age_seconds", ev.Age)
+				util.AlarmLevel(context.Background(), ev.Severity, ev.Alarm)
+			case !rec.Completed && !ev.Overdue:
+				m.Log.Info("crossTxCheck pending tx, not yet stale", "route", route.Name, "tx", tx.Hash,
+					"status", status, "status_str", statusStr, "age_seconds", ev.Age)
+			}
+		}
+	}
+	if n := store.Prune(now - int64(crossTxRetention.Seconds())); n > 0 {
+		m.Log.Info("crossTxCheck pruned completed txs", "count", n)
+	}
+	if err := store.Save(); err != nil {
+		m.Log.Error("crossTxCheck save tx store failed", "err", err)
+	}
+	status.Publish("crossTx", store.Pending())
+}
+
+// crossTxTracked returns the transactions of txs not completed yet in store,
+// followed by the pending ones of route that dropped out of txs.
+func crossTxTracked(store *blockstore.TxStore, route string, txs []crossTx) []crossTx {
+	seen := make(map[string]bool, len(txs))
+	var out []crossTx
+	for _, tx := range txs {
+		seen[tx.Hash] = true
+		if rec, ok := store.Get(tx.Hash); ok && rec.Completed {
 			continue
 		}
-		age := now - srcTs
-		if srcTs == 0 || age < int64(crossTxStaleAfter.Seconds()) {
-			m.Log.Info("crossTxCheck pending tx, not yet stale", "tx", txid,
-				"status", status, "status_str", statusStr, "age_seconds", age)
+		out = append(out, tx)
+	}
+	for _, rec := range store.Pending() {
+		if rec.Route == route && !seen[rec.Hash] {
+			out = append(out, crossTx{Hash: rec.Hash, Time: rec.SrcTime})
+		}
+	}
+	return out
+}
+
+// crossTxOrphans gives up the pending records of store whose route is not
+// in routes any more, so a route removed by a reload leaves no transfer
+// pending forever, and returns how many it gave up.
+func crossTxOrphans(store *blockstore.TxStore, routes map[string]bool) int {
+	n := 0
+	for _, rec := range store.Pending() {
+		if routes[rec.Route] {
 			continue
 		}
-		m.Log.Warn("crossTxCheck status abnormal", "tx", txid,
-			"status", status, "status_str", statusStr, "age_seconds", age)
-		util.Alarm(context.Background(),
-			fmt.Sprintf("cross tx not completed after %s, addr=%s tx=%s status=%d(%s)",
-				crossTxStaleAfter, addr, txid, status, statusStr))
+		rec.Completed, rec.GaveUp = true, true
+		store.Put(rec)
+		n++
 	}
+	return n
 }
 
-// fetchRecentTxids fetches up to `limit` confirmed txids for an address,
-// paginating blockstream.info's 25-per-page API.
-func fetchRecentTxids(client *http.Client, baseURL, address string, limit int) ([]string, error) {
-	var (
-		result []string
-		lastTx string
-	)
-	for len(result) < limit {
-		url := fmt.Sprintf("%s/address/%s/txs", baseURL, address)
-		if lastTx != "" {
-			url = fmt.Sprintf("%s/address/%s/txs/chain/%s", baseURL, address, lastTx)
+// crossTxEvent is what one status update of a transfer found.
+type crossTxEvent struct {
+	Age      int64
+	Overdue  bool
+	Alarm    string
+	Severity util.Severity
+}
+
+// crossTxUpdate records status in rec and returns the age of the transfer,
+// whether it is past the deadline of its status and the alarm the update
+// raises, if any. A status with After set replaces the route sla, and a
+// final status stops rec from being followed.
+func crossTxUpdate(rec *blockstore.TxRecord, status int, statusStr string, srcTs, now int64, sla time.Duration,
+	tss *config.Tss) crossTxEvent {
+	if srcTs != 0 {
+		rec.SrcTime = srcTs
+	}
+	last, ok := rec.Last()
+	changed := !ok || last.Status != status
+	if changed {
+		rec.History = append(rec.History, blockstore.TxStatus{Status: status, StatusStr: statusStr, Time: now})
+	}
+	if status == tss.OkStatus() {
+		age, _ := crossTxOverdue(rec.SrcTime, now, sla)
+		rec.Completed = true
+		if !rec.Overdue {
+			return crossTxEvent{Age: age}
 		}
+		return crossTxEvent{Age: age, Severity: util.SeverityInfo,
+			Alarm: fmt.Sprintf("cross tx completed after %ds, route=%s addr=%s tx=%s", age, rec.Route, rec.Bridge, rec.Hash)}
+	}
 
-		txs, err := getBlockstreamTxs(client, url)
+	rule := tss.CrossTxStatus(statusStr)
+	severity := util.Severity(rule.Severity)
+	if severity == "" {
+		severity = util.SeverityWarning
+	}
+	immediate := rule.After != nil && *rule.After == 0
+	if rule.After != nil {
+		sla = time.Duration(*rule.After) * time.Second
+	}
+	age, overdue := crossTxOverdue(rec.SrcTime, now, sla)
+	overdue = overdue || immediate
+	if rule.Final {
+		rec.Completed = true
+	}
+	ev := crossTxEvent{Age: age, Overdue: overdue, Severity: severity}
+	switch {
+	case !overdue:
+	case !rec.Overdue && immediate:
+		ev.Alarm = fmt.Sprintf("cross tx %s, route=%s addr=%s tx=%s status=%d(%s)",
+			statusStr, rec.Route, rec.Bridge, rec.Hash, status, statusStr)
+	case !rec.Overdue:
+		ev.Alarm = fmt.Sprintf("cross tx not completed after %s, route=%s addr=%s tx=%s status=%d(%s)",
+			sla, rec.Route, rec.Bridge, rec.Hash, status, statusStr)
+	case changed:
+		ev.Alarm = fmt.Sprintf("cross tx status changed, route=%s addr=%s tx=%s status=%d(%s) -> %d(%s)",
+			rec.Route, rec.Bridge, rec.Hash, last.Status, last.StatusStr, status, statusStr)
+	}
+	if overdue {
+		rec.Overdue = true
+	}
+	return ev
+}
+
+// crossTxGiveUp completes rec once it has been followed for maxAge since
+// its source transaction (or since first seen, when that time is unknown)
+// and returns the alarm saying so, or "" while rec is still followed.
+func crossTxGiveUp(rec *blockstore.TxRecord, now int64, maxAge time.Duration) string {
+	start := rec.SrcTime
+	if start == 0 {
+		start = rec.FirstSeen
+	}
+	if rec.Completed || maxAge <= 0 || now-start < int64(maxAge.Seconds()) {
+		return ""
+	}
+	rec.Completed, rec.GaveUp = true, true
+	last, _ := rec.Last()
+	return fmt.Sprintf("cross tx not completed after %s, no longer tracked, route=%s addr=%s tx=%s status=%d(%s)",
+		maxAge, rec.Route, rec.Bridge, rec.Hash, last.Status, last.StatusStr)
+}
+
+// crossTxOverdue returns the age of a transfer and whether it is past sla.
+// A transfer of unknown age is never overdue.
+func crossTxOverdue(srcTs, now int64, sla time.Duration) (int64, bool) {
+	if srcTs == 0 {
+		return 0, false
+	}
+	age := now - srcTs
+	return age, age >= int64(sla.Seconds())
+}
+
+// crossTxStore returns the tx store under tss.StorePath, opening it again
+// after a config reload changed the path.
+func (m *Monitor) crossTxStore(tss *config.Tss) (*blockstore.TxStore, error) {
+	if m.txStore != nil && m.txStorePath == tss.StorePath {
+		return m.txStore, nil
+	}
+	store, err := blockstore.NewTxStore(tss.StorePath, m.Cfg.Id, m.Cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	m.txStore, m.txStorePath = store, tss.StorePath
+	return store, nil
+}
+
+// crossTxRoutes returns the routes of tss. The source of each route is
+// built once and kept while its settings stay the same, so a route that can
+// not be built is logged, skipped and retried on the next call without
+// touching the others, and the clients of routes a reload changed or
+// removed are closed.
+func (m *Monitor) crossTxRoutes(tss *config.Tss) []crossTxRoute {
+	limit, sla := tss.RouteLimit(), tss.StaleAfterDuration()
+	built := make(map[string]crossTxSource, len(m.crossSources))
+	source := func(key string, build func() (crossTxSource, error)) (crossTxSource, error) {
+		if src, ok := m.crossSources[key]; ok {
+			built[key] = src
+			return src, nil
+		}
+		src, err := build()
 		if err != nil {
 			return nil, err
 		}
-		if len(txs) == 0 {
-			break
+		built[key] = src
+		return src, nil
+	}
+
+	var routes []crossTxRoute
+	if esplora := tssEsplora(tss); tss.BtcAddress != "" && esplora != nil {
+		key := fmt.Sprintf("btc|%+v|%s", *esplora, tss.BtcAddress)
+		src, err := source(key, func() (crossTxSource, error) { return newBtcSource(esplora, tss.BtcAddress) })
+		if err != nil {
+			m.Log.Error("crossTxCheck build btc route failed", "addr", tss.BtcAddress, "err", err)
+		} else {
+			routes = append(routes, crossTxRoute{Name: "btc", Bridge: tss.BtcAddress, Source: src,
+				Limit: limit, Sla: sla})
 		}
-		for _, tx := range txs {
-			result = append(result, tx.Txid)
-			if len(result) >= limit {
-				break
+	}
+	for _, cfg := range tss.CrossTxSources {
+		key := fmt.Sprintf("%s|%s|%s|%s|%d", cfg.Type, cfg.Endpoint, cfg.Bridge, strings.Join(cfg.Topics, ","), cfg.Blocks)
+		src, err := source(key, func() (crossTxSource, error) { return newCrossTxSource(cfg) })
+		if err != nil {
+			m.Log.Error("crossTxCheck build route failed", "route", cfg.Name, "err", err)
+			continue
+		}
+		route := crossTxRoute{Name: cfg.Name, Bridge: cfg.Bridge, Source: src, Limit: cfg.Limit,
+			Sla: time.Duration(cfg.Sla) * time.Second}
+		if route.Limit <= 0 {
+			route.Limit = limit
+		}
+		if route.Sla <= 0 {
+			route.Sla = sla
+		}
+		routes = append(routes, route)
+	}
+
+	for key, src := range m.crossSources {
+		if _, ok := built[key]; !ok {
+			if closer, ok := src.(interface{ Close() }); ok {
+				closer.Close()
 			}
 		}
-		lastTx = txs[len(txs)-1].Txid
 	}
-	return result, nil
+	m.crossSources = built
+	return routes
 }
 
-func getBlockstreamTxs(client *http.Client, url string) ([]blockstreamTx, error) {
-	resp, err := client.Get(url)
+// crossTxRouteNames returns the names of the routes tss configures, built
+// or not: records of a route whose source failed to build are still followed.
+func crossTxRouteNames(tss *config.Tss) map[string]bool {
+	names := make(map[string]bool, len(tss.CrossTxSources)+1)
+	if tss.BtcAddress != "" && tssEsplora(tss) != nil {
+		names["btc"] = true
+	}
+	for _, cfg := range tss.CrossTxSources {
+		names[cfg.Name] = true
+	}
+	return names
+}
+
+func newBtcSource(esplora *config.Esplora, addr string) (*btcSource, error) {
+	client, err := newEsploraClient(esplora)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("blockstream returned %d: %s", resp.StatusCode, string(body))
-	}
-	var txs []blockstreamTx
-	if err := json.NewDecoder(resp.Body).Decode(&txs); err != nil {
+	address, err := btcutil.DecodeAddress(addr, client.NetParams())
+	if err != nil {
 		return nil, err
 	}
-	return txs, nil
+	return &btcSource{client: client, address: address}, nil
+}
+
+// tssEsplora returns the API configured for the TSS BTC address: Esplora,
+// else BlockstreamUrl, else nil.
+func tssEsplora(tss *config.Tss) *config.Esplora {
+	if tss.Esplora != nil {
+		return tss.Esplora
+	}
+	if tss.BlockstreamUrl != "" {
+		return &config.Esplora{Url: tss.BlockstreamUrl}
+	}
+	return nil
+}
+
+// fetchRecentTxids fetches up to `limit` txids for an address, following
+// Esplora's paginated /address/:addr/txs/chain API.
+func fetchRecentTxids(client *mempool.MempoolClient, address btcutil.Address, limit int) ([]string, error) {
+	var (
+		result []string
+		lastTx string
+	)
+	for len(result) < limit {
+		txids, err := client.AddressTxids(address, lastTx)
+		if err != nil {
+			return nil, err
+		}
+		if len(txids) == 0 {
+			break
+		}
+		for _, txid := range txids {
+			result = append(result, txid)
+			if len(result) >= limit {
+				break
+			}
+		}
+		lastTx = txids[len(txids)-1]
+	}
+	return result, nil
 }
 
 func fetchCrossTxStatus(client *http.Client, baseURL, txid string) (status int, statusStr string, srcTimestamp int64, err error) {
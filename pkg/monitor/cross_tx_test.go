@@ -0,0 +1,362 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/blockstore"
+	"github.com/mapprotocol/monitor/pkg/mempool"
+	"github.com/mapprotocol/monitor/pkg/util"
+)
+
+func TestFetchRecentTxids_Paginates(t *testing.T) {
+	const addr = "bc1pv5lu5aklz64sye9f4zmnjkfg8j6s2tllu3fem4cs9t0hcrnz5e7qy0qw6e"
+	pages := map[string][]string{
+		"/address/" + addr + "/txs":          {"a1", "a2", "a3"},
+		"/address/" + addr + "/txs/chain/a3": {"b1", "b2", "b3"},
+		"/address/" + addr + "/txs/chain/b3": {},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		txids, ok := pages[req.URL.Path]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		items := make([]string, 0, len(txids))
+		for _, txid := range txids {
+			items = append(items, fmt.Sprintf(`{"txid":%q}`, txid))
+		}
+		_, _ = rw.Write([]byte("[" + strings.Join(items, ",") + "]"))
+	}))
+	defer server.Close()
+
+	client, err := newEsploraClient(&config.Esplora{Url: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := btcutil.DecodeAddress(addr, client.NetParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fetchRecentTxids(client, address, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "a1,a2,a3,b1,b2" {
+		t.Fatalf("fetchRecentTxids = %v, want first 5 across pages", got)
+	}
+
+	got, err = fetchRecentTxids(client, address, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 6 {
+		t.Fatalf("fetchRecentTxids returned %d txids, want all 6", len(got))
+	}
+}
+
+func TestNewEsploraClient_InvalidNetwork(t *testing.T) {
+	if _, err := newEsploraClient(&config.Esplora{Network: "litecoin"}); err == nil {
+		t.Fatal("expected error for unknown network")
+	}
+}
+
+func TestPendingOutflowAlarm(t *testing.T) {
+	const addr = "bc1pv5lu5aklz64sye9f4zmnjkfg8j6s2tllu3fem4cs9t0hcrnz5e7qy0qw6e"
+	tests := []struct {
+		name      string
+		balances  mempool.Balances
+		waterLine int64
+		wantAlarm bool
+	}{
+		{name: "no mempool activity", balances: mempool.Balances{Confirmed: 5e8, Effective: 5e8}, waterLine: 1e8},
+		{name: "pending inflow", balances: mempool.Balances{Confirmed: 5e8, Pending: 3e8, Effective: 8e8}, waterLine: 1e8},
+		{name: "outflow at waterLine", balances: mempool.Balances{Confirmed: 5e8, Pending: -1e8, Effective: 4e8}, waterLine: 1e8},
+		{name: "outflow above waterLine", balances: mempool.Balances{Confirmed: 5e8, Pending: -3e8, Effective: 2e8}, waterLine: 1e8, wantAlarm: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := pendingOutflowAlarm(addr, &tt.balances, tt.waterLine)
+			if (msg != "") != tt.wantAlarm {
+				t.Fatalf("pendingOutflowAlarm = %q, wantAlarm %v", msg, tt.wantAlarm)
+			}
+		})
+	}
+}
+
+func TestCrossTxOverdue(t *testing.T) {
+	const now = 1700000000
+	if age, overdue := crossTxOverdue(now-3600, now, 2*time.Hour); age != 3600 || overdue {
+		t.Fatalf("within sla: age=%d overdue=%v", age, overdue)
+	}
+	if age, overdue := crossTxOverdue(now-600, now, 5*time.Minute); age != 600 || !overdue {
+		t.Fatalf("past sla: age=%d overdue=%v", age, overdue)
+	}
+	if _, overdue := crossTxOverdue(0, now, time.Second); overdue {
+		t.Fatal("a transfer of unknown age must not be overdue")
+	}
+}
+
+func TestCrossTxUpdate(t *testing.T) {
+	const start = 1700000000
+	tss := &config.Tss{}
+	rec := &blockstore.TxRecord{Hash: "0xabc", Route: "eth->btc", Bridge: "0xbridge", FirstSeen: start}
+
+	steps := []struct {
+		after  int64
+		status int
+		alarm  string
+	}{
+		{after: 0, status: 1},                             // pending, within sla
+		{after: 1800, status: 2},                          // moves on, within sla
+		{after: 3600, status: 2, alarm: "not completed"},  // passes sla
+		{after: 3660, status: 2},                          // still stuck, alarmed once
+		{after: 3720, status: 5, alarm: "status changed"}, // transition while overdue
+		{after: 3780, status: 5},                          // no change
+		{after: 3840, status: config.DefaultCrossTxStatusOk, alarm: "completed"},
+	}
+	for i, step := range steps {
+		ev := crossTxUpdate(rec, step.status, fmt.Sprint(step.status), start, start+step.after, time.Hour, tss)
+		if (ev.Alarm == "") != (step.alarm == "") || !strings.Contains(ev.Alarm, step.alarm) {
+			t.Fatalf("step %d: alarm %q, want %q", i, ev.Alarm, step.alarm)
+		}
+	}
+	if !rec.Completed || len(rec.History) != 4 {
+		t.Fatalf("record = %+v", rec)
+	}
+
+	quick := &blockstore.TxRecord{Hash: "0xdef"}
+	if ev := crossTxUpdate(quick, config.DefaultCrossTxStatusOk, "ok", start, start+60, time.Hour, tss); ev.Alarm != "" || !quick.Completed {
+		t.Fatalf("in-time completion: alarm %q record %+v", ev.Alarm, quick)
+	}
+}
+
+func TestCrossTxUpdate_Statuses(t *testing.T) {
+	const start = 1700000000
+	after := int64(600)
+	ok := 7
+	tss := &config.Tss{StatusOk: &ok, Statuses: map[string]config.CrossTxStatus{
+		"stuck":    {Severity: "critical", After: &after},
+		"refunded": {Severity: "info"}, // overrides the default
+	}}
+
+	failed := &blockstore.TxRecord{Hash: "0x1"}
+	ev := crossTxUpdate(failed, 9, "failed", start, start+10, time.Hour, tss)
+	if ev.Severity != util.SeverityCritical || !strings.Contains(ev.Alarm, "cross tx failed") || !failed.Completed {
+		t.Fatalf("failed alarms at once: %+v record %+v", ev, failed)
+	}
+
+	refunded := &blockstore.TxRecord{Hash: "0x2"}
+	if ev := crossTxUpdate(refunded, 8, "refunded", start, start+10, time.Hour, tss); ev.Alarm != "" || refunded.Completed {
+		t.Fatalf("configured refunded waits for the sla: %+v record %+v", ev, refunded)
+	}
+
+	stuck := &blockstore.TxRecord{Hash: "0x3"}
+	if ev := crossTxUpdate(stuck, 2, "stuck", start, start+300, time.Hour, tss); ev.Alarm != "" {
+		t.Fatalf("stuck alarmed before its deadline: %+v", ev)
+	}
+	ev = crossTxUpdate(stuck, 2, "stuck", start, start+600, time.Hour, tss)
+	if ev.Severity != util.SeverityCritical || !strings.Contains(ev.Alarm, "not completed after 10m0s") {
+		t.Fatalf("stuck at its deadline: %+v", ev)
+	}
+
+	done := &blockstore.TxRecord{Hash: "0x4"}
+	if ev := crossTxUpdate(done, 7, "done", start, start+7200, time.Hour, tss); ev.Alarm != "" || !done.Completed {
+		t.Fatalf("statusOk completes: %+v record %+v", ev, done)
+	}
+}
+
+func TestCrossTxTracked(t *testing.T) {
+	store, err := blockstore.NewTxStore(t.TempDir(), 22776, "map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Put(blockstore.TxRecord{Hash: "done", Route: "btc", Completed: true})
+	store.Put(blockstore.TxRecord{Hash: "stuck", Route: "btc", SrcTime: 100})
+	store.Put(blockstore.TxRecord{Hash: "other", Route: "eth->btc"})
+
+	txs := crossTxTracked(store, "btc", []crossTx{{Hash: "new"}, {Hash: "done"}})
+	if len(txs) != 2 || txs[0].Hash != "new" || txs[1].Hash != "stuck" || txs[1].Time != 100 {
+		t.Fatalf("tracked = %+v", txs)
+	}
+}
+
+func TestCrossTxOrphans(t *testing.T) {
+	store, err := blockstore.NewTxStore(t.TempDir(), 22776, "map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Put(blockstore.TxRecord{Hash: "kept", Route: "btc", FirstSeen: 100})
+	store.Put(blockstore.TxRecord{Hash: "orphan", Route: "eth->btc", FirstSeen: 100})
+
+	tss := &config.Tss{BtcAddress: "bc1q", Esplora: &config.Esplora{}}
+	if n := crossTxOrphans(store, crossTxRouteNames(tss)); n != 1 {
+		t.Fatalf("gave up %d, want 1", n)
+	}
+	if pending := store.Pending(); len(pending) != 1 || pending[0].Hash != "kept" {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if rec, _ := store.Get("orphan"); !rec.Completed || !rec.GaveUp {
+		t.Fatalf("orphan not given up: %+v", rec)
+	}
+}
+
+func TestCrossTxGiveUp(t *testing.T) {
+	maxAge := crossTxTrackSlas * time.Hour
+	rec := blockstore.TxRecord{Hash: "0xdeposit", Route: "btc", Bridge: "bc1q", SrcTime: 1000,
+		History: []blockstore.TxStatus{{Status: 0, StatusStr: "unknown", Time: 1000}}}
+	if alarm := crossTxGiveUp(&rec, 1000+int64(maxAge.Seconds())-1, maxAge); alarm != "" || rec.Completed {
+		t.Fatalf("given up early: %q %+v", alarm, rec)
+	}
+	alarm := crossTxGiveUp(&rec, 1000+int64(maxAge.Seconds()), maxAge)
+	if !strings.Contains(alarm, "no longer tracked") || !strings.Contains(alarm, "status=0(unknown)") {
+		t.Fatalf("alarm = %q", alarm)
+	}
+	if !rec.Completed || !rec.GaveUp {
+		t.Fatalf("record not final: %+v", rec)
+	}
+	if alarm := crossTxGiveUp(&rec, 1000+2*int64(maxAge.Seconds()), maxAge); alarm != "" {
+		t.Fatalf("alarmed twice: %q", alarm)
+	}
+
+	// without a source time the age counts from first seen
+	unknown := blockstore.TxRecord{Hash: "0xunknown", FirstSeen: 5000}
+	if alarm := crossTxGiveUp(&unknown, 5000+int64(maxAge.Seconds()), maxAge); alarm == "" {
+		t.Fatal("unknown tx never given up")
+	}
+
+	// a given-up record is pruned with the completed ones and no longer followed
+	store, err := blockstore.NewTxStore(t.TempDir(), 22776, "map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Put(rec)
+	if txs := crossTxTracked(store, "btc", nil); len(txs) != 0 {
+		t.Fatalf("tracked = %+v", txs)
+	}
+	if n := store.Prune(rec.FirstSeen + 1); n != 1 {
+		t.Fatalf("pruned %d", n)
+	}
+}
+
+func TestCrossTxRoutes_CachedPerRoute(t *testing.T) {
+	srv := httptest.NewServer(&fakeChain{head: 10})
+	defer srv.Close()
+	m := newFakeMonitor(t, &fakeChain{})
+	good := config.CrossTxSource{Name: "eth->btc", Endpoint: srv.URL, Bridge: testToken.Hex()}
+	bad := config.CrossTxSource{Name: "bad", Endpoint: srv.URL, Bridge: "not-an-address"}
+
+	routes := m.crossTxRoutes(&config.Tss{CrossTxSources: []config.CrossTxSource{good, bad}})
+	if len(routes) != 1 || routes[0].Name != "eth->btc" {
+		t.Fatalf("routes = %+v", routes)
+	}
+	first := routes[0].Source
+
+	// a reload that fixes the failed route keeps the client of the good one
+	bad.Bridge = testHolder1.Hex()
+	routes = m.crossTxRoutes(&config.Tss{CrossTxSources: []config.CrossTxSource{good, bad}})
+	if len(routes) != 2 || routes[0].Source != first {
+		t.Fatalf("routes = %+v, want the eth->btc source kept", routes)
+	}
+
+	// a reload that moves a route rebuilds it and closes the old client
+	old := &closingSource{}
+	m.crossSources["replaced"] = old
+	good.Endpoint = srv.URL + "/"
+	routes = m.crossTxRoutes(&config.Tss{CrossTxSources: []config.CrossTxSource{good, bad}})
+	if routes[0].Source == first {
+		t.Fatal("changed route not rebuilt")
+	}
+	if !old.closed || len(m.crossSources) != 2 {
+		t.Fatalf("closed=%v cached=%d, want the replaced source closed and 2 cached", old.closed, len(m.crossSources))
+	}
+}
+
+type closingSource struct{ closed bool }
+
+func (s *closingSource) recentTxs(context.Context, int) ([]crossTx, error) { return nil, nil }
+
+func (s *closingSource) Close() { s.closed = true }
+
+func TestEvmSource_RecentTxs(t *testing.T) {
+	bridge := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	tx1, tx2 := common.HexToHash("0x01"), common.HexToHash("0x02")
+	f := &fakeChain{head: 5000, logs: []types.Log{
+		{Address: bridge, TxHash: tx1, BlockNumber: 4000, BlockTimestamp: 100, Topics: []common.Hash{{}}},
+		{Address: bridge, TxHash: tx2, BlockNumber: 4990, BlockTimestamp: 200, Topics: []common.Hash{{}}},
+		{Address: bridge, TxHash: tx2, BlockNumber: 4990, BlockTimestamp: 200, Topics: []common.Hash{{}}, Index: 1},
+	}}
+	m := newFakeMonitor(t, f)
+	src := &evmSource{client: m.Conn.Client(), bridge: bridge, blocks: 1000}
+
+	txs, err := src.recentTxs(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 2 || txs[0].Hash != tx2.Hex() || txs[0].Time != 200 || txs[1].Hash != tx1.Hex() {
+		t.Fatalf("txs = %+v", txs)
+	}
+	if txs, _ = src.recentTxs(context.Background(), 1); len(txs) != 1 {
+		t.Fatalf("limit ignored: %+v", txs)
+	}
+}
+
+func TestTronSource_RecentTxs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/accounts/TBridge/transactions" || req.URL.Query().Get("only_to") != "true" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = rw.Write([]byte(`{"data":[
+			{"txID":"aa","block_timestamp":1700000000000,"ret":[{"contractRet":"SUCCESS"}]},
+			{"txID":"bb","block_timestamp":1700000001000,"ret":[{"contractRet":"REVERT"}]}]}`))
+	}))
+	defer server.Close()
+
+	src, err := newCrossTxSource(config.CrossTxSource{Name: "tron", Type: config.Tron, Endpoint: server.URL, Bridge: "TBridge"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs, err := src.recentTxs(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 1 || txs[0].Hash != "aa" || txs[0].Time != 1700000000 {
+		t.Fatalf("txs = %+v", txs)
+	}
+}
+
+func TestSolSource_RecentTxs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var r rpcRequest
+		_ = json.NewDecoder(req.Body).Decode(&r)
+		_, _ = fmt.Fprintf(rw, `{"jsonrpc":"2.0","id":%s,"result":[
+			{"signature":"5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW","slot":1,"blockTime":1700000000,"err":null},
+			{"signature":"4NCYB3kRT8sCNodPNuCZo8VUh4xqpBQxsxed2wd9xaD4PGQ43BSTQJPF8tpTBMbL8NU7v1nhEFBVbScS1W5Rmrt8","slot":2,"err":{"InstructionError":[0,"Custom"]}}]}`, r.ID)
+	}))
+	defer server.Close()
+
+	src, err := newCrossTxSource(config.CrossTxSource{Name: "sol", Type: config.Sol, Endpoint: server.URL,
+		Bridge: "11111111111111111111111111111111"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs, err := src.recentTxs(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 1 || !strings.HasPrefix(txs[0].Hash, "5VERv8") || txs[0].Time != 1700000000 {
+		t.Fatalf("txs = %+v", txs)
+	}
+}
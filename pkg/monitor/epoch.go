@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/internal/mapprotocol"
+	"github.com/mapprotocol/monitor/pkg/util"
+)
+
+// epochState is the last epoch tssCheck saw on the maintainer contract.
+type epochState struct {
+	Id   *big.Int // nil until the first epoch is read
+	Info EpochInfo
+}
+
+// epochEvents is what one epoch reading reports: Notices are informational,
+// Alarms need attention.
+type epochEvents struct {
+	Notices []string
+	Alarms  []string
+}
+
+// epochCheck follows epoch rotations of the maintainer contract. It posts a
+// notice on every rotation and alarms when an epoch runs past its EndBlock,
+// when key migration does not finish in time, or when the maintainer set
+// changes size unexpectedly.
+func (m *Monitor) epochCheck(epoch *big.Int, info EpochInfo) {
+	cfg := m.Cfg.Tss.Epoch
+	if cfg == nil {
+		return
+	}
+	head, err := mapprotocol.GlobalMapConn.BlockNumber(context.Background())
+	if err != nil {
+		m.Log.Error("Epoch check, get block number failed", "err", err)
+		return
+	}
+	m.Log.Info("Epoch check", "epoch", epoch, "head", head, "start", info.StartBlock, "end", info.EndBlock,
+		"migrated", info.MigratedBlock, "maintainers", len(info.Maintainers))
+	events := evaluateEpoch(&m.epoch, epoch, info, head, cfg)
+	for _, notice := range events.Notices {
+		util.AlarmLevel(context.Background(), util.SeverityInfo, notice)
+	}
+	for _, alarm := range events.Alarms {
+		util.Alarm(context.Background(), alarm)
+	}
+}
+
+// evaluateEpoch folds the current epoch into state and reports what changed
+// or is overdue at block head.
+func evaluateEpoch(state *epochState, epoch *big.Int, info EpochInfo, head uint64, cfg *config.EpochCheck) epochEvents {
+	var events epochEvents
+	rotated := state.Id != nil && state.Id.Cmp(epoch) != 0
+	if rotated {
+		events.Notices = append(events.Notices, fmt.Sprintf("TSS epoch rotated, epoch=%s->%s maintainers=%d->%d startBlock=%d",
+			state.Id, epoch, len(state.Info.Maintainers), len(info.Maintainers), info.StartBlock))
+	}
+	if cfg.Maintainers > 0 {
+		if len(info.Maintainers) != cfg.Maintainers {
+			events.Alarms = append(events.Alarms, fmt.Sprintf("TSS maintainer set size unexpected, epoch=%s maintainers=%d want %d",
+				epoch, len(info.Maintainers), cfg.Maintainers))
+		}
+	} else if rotated && len(info.Maintainers) != len(state.Info.Maintainers) {
+		events.Alarms = append(events.Alarms, fmt.Sprintf("TSS maintainer set size changed, epoch=%s maintainers=%d->%d",
+			epoch, len(state.Info.Maintainers), len(info.Maintainers)))
+	}
+	if info.EndBlock != 0 && head > info.EndBlock+cfg.OverdueBlocks {
+		events.Alarms = append(events.Alarms, fmt.Sprintf("TSS epoch overdue, epoch=%s endBlock=%d head=%d, no new epoch started",
+			epoch, info.EndBlock, head))
+	}
+	// the first epoch has no keys to migrate from
+	if cfg.MigrationBlocks > 0 && epoch.Cmp(big.NewInt(1)) > 0 && info.MigratedBlock == 0 &&
+		head > info.StartBlock+cfg.MigrationBlocks {
+		events.Alarms = append(events.Alarms, fmt.Sprintf("TSS key migration unfinished, epoch=%s startBlock=%d head=%d",
+			epoch, info.StartBlock, head))
+	}
+	state.Id, state.Info = new(big.Int).Set(epoch), info
+	return events
+}
@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mapprotocol/monitor/internal/config"
+)
+
+func TestEvaluateEpoch(t *testing.T) {
+	cfg := &config.EpochCheck{OverdueBlocks: 100, MigrationBlocks: 50}
+	three := []common.Address{testHolder1, testHolder2, testToken}
+	var state epochState
+
+	first := EpochInfo{StartBlock: 1000, EndBlock: 2000, Maintainers: three}
+	if events := evaluateEpoch(&state, big.NewInt(1), first, 1500, cfg); len(events.Notices)+len(events.Alarms) != 0 {
+		t.Fatalf("first reading reported %+v", events)
+	}
+	if events := evaluateEpoch(&state, big.NewInt(1), first, 2101, cfg); len(events.Alarms) != 1 ||
+		!strings.HasPrefix(events.Alarms[0], "TSS epoch overdue") {
+		t.Fatalf("overdue epoch: %+v", events)
+	}
+
+	second := EpochInfo{StartBlock: 2100, EndBlock: 3100, Maintainers: three[:2]}
+	events := evaluateEpoch(&state, big.NewInt(2), second, 2120, cfg)
+	if len(events.Notices) != 1 || !strings.Contains(events.Notices[0], "epoch=1->2") ||
+		len(events.Alarms) != 1 || !strings.Contains(events.Alarms[0], "maintainers=3->2") {
+		t.Fatalf("rotation: %+v", events)
+	}
+	if events = evaluateEpoch(&state, big.NewInt(2), second, 2151, cfg); len(events.Alarms) != 1 ||
+		!strings.HasPrefix(events.Alarms[0], "TSS key migration unfinished") {
+		t.Fatalf("migration: %+v", events)
+	}
+	second.MigratedBlock = 2140
+	if events = evaluateEpoch(&state, big.NewInt(2), second, 2151, cfg); len(events.Alarms) != 0 {
+		t.Fatalf("migrated epoch alarmed: %+v", events)
+	}
+
+	cfg.Maintainers = 3
+	if events = evaluateEpoch(&state, big.NewInt(2), second, 2151, cfg); len(events.Alarms) != 1 ||
+		!strings.Contains(events.Alarms[0], "maintainers=2 want 3") {
+		t.Fatalf("expected size: %+v", events)
+	}
+}
@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/util"
+)
+
+// gasFees is one gas reading of a chain, in wei.
+type gasFees struct {
+	BaseFee *big.Int // nil before London, Price is then the legacy gas price
+	Tip     *big.Int
+	Price   *big.Int // what a relay pays per gas: BaseFee + Tip
+}
+
+// gasCheck reads the chain's gas fees, alarms when they exceed the
+// configured ceilings and when a sender can no longer afford MinRelays
+// relays at the current price.
+func (m *Monitor) gasCheck(snap config.OptConfig, balances balanceSet) {
+	fees, err := readGasFees(context.Background(), m.Conn.Client())
+	if err != nil {
+		m.Log.Error("Gas check failed", "err", err)
+		return
+	}
+	m.Log.Info("Gas check", "baseFee", fees.BaseFee, "tip", fees.Tip, "price", fees.Price)
+
+	for _, reason := range evaluateGas(fees, snap.Gas) {
+		util.Alarm(context.Background(), fmt.Sprintf("Gas price spike,chains=%s %s", snap.Name, reason))
+	}
+
+	if snap.Gas.MinRelays == 0 {
+		return
+	}
+	for _, addr := range senderAddresses(snap) {
+		bal, err := balances.get(balanceKey{Holder: addr})
+		if err != nil {
+			m.Log.Error("Relay budget check failed", "addr", addr, "err", err)
+			continue
+		}
+		relays := affordableRelays(bal, fees.Price, snap.Gas.RelayGas)
+		m.Log.Info("Relay budget", "addr", addr, "relays", relays)
+		if relays < snap.Gas.MinRelays {
+			util.Alarm(context.Background(), fmt.Sprintf("Relay budget low,chains=%s addr=%s balance=%s relays=%d minRelays=%d gasPrice=%s gwei",
+				snap.Name, addr, amount.New(bal, nativeDecimals), relays, snap.Gas.MinRelays, amount.New(fees.Price, 9)))
+		}
+	}
+}
+
+// readGasFees reads the base fee of the latest header and the suggested
+// priority fee. Chains without a base fee report the legacy gas price.
+func readGasFees(ctx context.Context, client *ethclient.Client) (gasFees, error) {
+	var fees gasFees
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fees, err
+	}
+	if head.BaseFee == nil {
+		price, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return fees, err
+		}
+		fees.Tip, fees.Price = new(big.Int), price
+		return fees, nil
+	}
+	fees.BaseFee = head.BaseFee
+	fees.Tip, err = client.SuggestGasTipCap(ctx)
+	if err != nil {
+		// not every chain serves eth_maxPriorityFeePerGas
+		fees.Tip = new(big.Int)
+	}
+	fees.Price = new(big.Int).Add(fees.BaseFee, fees.Tip)
+	return fees, nil
+}
+
+// evaluateGas returns why fees should alarm under cfg, if at all.
+func evaluateGas(fees gasFees, cfg config.GasCheck) []string {
+	var reasons []string
+	if cfg.AlarmPrice != nil && fees.Price.Cmp(cfg.AlarmPrice) > 0 {
+		reasons = append(reasons, fmt.Sprintf("gasPrice=%s gwei above gasAlarmPrice=%s gwei",
+			amount.New(fees.Price, 9), amount.New(cfg.AlarmPrice, 9)))
+	}
+	if cfg.MaxPriorityFee != nil && fees.Tip.Cmp(cfg.MaxPriorityFee) > 0 {
+		reasons = append(reasons, fmt.Sprintf("priorityFee=%s gwei above maxPriorityFee=%s gwei",
+			amount.New(fees.Tip, 9), amount.New(cfg.MaxPriorityFee, 9)))
+	}
+	return reasons
+}
+
+// affordableRelays is how many relays of relayGas gas balance pays for at
+// price per gas.
+func affordableRelays(balance, price *big.Int, relayGas uint64) uint64 {
+	cost := new(big.Int).Mul(price, new(big.Int).SetUint64(relayGas))
+	if cost.Sign() <= 0 {
+		return ^uint64(0)
+	}
+	relays := new(big.Int).Quo(balance, cost)
+	if !relays.IsUint64() {
+		return ^uint64(0)
+	}
+	return relays.Uint64()
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/mapprotocol/monitor/internal/config"
+)
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9))
+}
+
+func TestReadGasFees(t *testing.T) {
+	f := &fakeChain{baseFee: gwei(30), tip: 2e9, gasPrice: 50e9}
+	m := newFakeMonitor(t, f)
+
+	fees, err := readGasFees(context.Background(), m.Conn.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.BaseFee.Cmp(gwei(30)) != 0 || fees.Tip.Cmp(gwei(2)) != 0 || fees.Price.Cmp(gwei(32)) != 0 {
+		t.Fatalf("fees = %+v", fees)
+	}
+
+	// before London the legacy gas price is what a relay pays
+	f.baseFee = nil
+	if fees, err = readGasFees(context.Background(), m.Conn.Client()); err != nil {
+		t.Fatal(err)
+	}
+	if fees.BaseFee != nil || fees.Price.Cmp(gwei(50)) != 0 {
+		t.Fatalf("legacy fees = %+v", fees)
+	}
+}
+
+func TestEvaluateGas(t *testing.T) {
+	fees := gasFees{BaseFee: gwei(30), Tip: gwei(5), Price: gwei(35)}
+	if reasons := evaluateGas(fees, config.GasCheck{}); len(reasons) != 0 {
+		t.Fatalf("unset ceilings alarmed: %v", reasons)
+	}
+	if reasons := evaluateGas(fees, config.GasCheck{AlarmPrice: gwei(35), MaxPriorityFee: gwei(5)}); len(reasons) != 0 {
+		t.Fatalf("ceilings are inclusive, got %v", reasons)
+	}
+	if reasons := evaluateGas(fees, config.GasCheck{AlarmPrice: gwei(20), MaxPriorityFee: gwei(2)}); len(reasons) != 2 {
+		t.Fatalf("expected both ceilings to alarm, got %v", reasons)
+	}
+}
+
+func TestAffordableRelays(t *testing.T) {
+	// 1 ETH at 20 gwei and 500k gas a relay is 0.01 ETH a relay
+	balance := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	if got := affordableRelays(balance, gwei(20), 500000); got != 100 {
+		t.Fatalf("relays = %d, want 100", got)
+	}
+	if got := affordableRelays(big.NewInt(1), gwei(20), 500000); got != 0 {
+		t.Fatalf("relays = %d, want 0", got)
+	}
+}
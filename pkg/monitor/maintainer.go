@@ -0,0 +1,200 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/maintainer"
+	"github.com/mapprotocol/monitor/pkg/util"
+)
+
+// maintainerHealth is what was found wrong with one maintainer in a tick.
+// Reachable is whether its status endpoints were all fine, which is what
+// counts towards the signing quorum.
+type maintainerHealth struct {
+	Info      MaintainerInfo
+	Reasons   []string
+	Reachable bool
+}
+
+// maintainerReport checks every elected maintainer, on chain and through
+// its status endpoints probed concurrently, and posts the problems of all of
+// them as one report for the epoch. It catches a maintainer that stopped
+// signing even while its HTTP port still answers.
+func (m *Monitor) maintainerReport(epoch *big.Int, infos []MaintainerInfo) {
+	tss := m.Cfg.Tss
+	client, err := m.probeClient(tss)
+	if err != nil {
+		m.Log.Error("Maintainer probe, build client failed", "err", err)
+		return
+	}
+	hosts := make([]string, 0, len(infos))
+	for _, info := range infos {
+		hosts = append(hosts, info.P2pAddress)
+	}
+	results := client.Probe(context.Background(), hosts)
+
+	now := time.Now()
+	healths := make([]maintainerHealth, 0, len(infos))
+	for i, info := range infos {
+		m.Log.Info("Maintainer status", "address", info.Account, "ip", info.P2pAddress, "status", info.Status,
+			"lastHeartbeat", info.LastHeartbeatTime, "lastActiveEpoch", info.LastActiveEpoch, "epoch", epoch)
+		var reasons []string
+		if tss.Health != nil {
+			reasons = evaluateMaintainer(info, epoch, now, tss.Health)
+		}
+		probeReasons := evaluateProbe(results[i], tss.ScannerGap)
+		reasons = append(reasons, probeReasons...)
+		healths = append(healths, maintainerHealth{Info: info, Reasons: reasons, Reachable: len(probeReasons) == 0})
+	}
+	m.meshCheck(epoch, infos, results)
+	if tss.Quorum != nil {
+		if msg, ok := evaluateQuorum(epoch, healths, tss.Quorum); ok {
+			util.AlarmLevel(context.Background(), util.SeverityCritical, msg)
+		}
+	}
+	if report, ok := formatMaintainerReport(epoch, healths); ok {
+		util.Alarm(context.Background(), report)
+	} else {
+		m.Log.Info("Maintainer report, all healthy", "epoch", epoch, "maintainers", len(infos))
+	}
+}
+
+// probeClient returns the probe client of tss, building it again after a
+// config reload changed tss.probe and closing the idle connections of the
+// client it replaces.
+func (m *Monitor) probeClient(tss *config.Tss) (*maintainer.Client, error) {
+	var opts maintainer.Options
+	if p := tss.Probe; p != nil {
+		opts = maintainer.Options{
+			Port:        p.Port,
+			Scheme:      p.Scheme,
+			CaFile:      p.CaFile,
+			Timeout:     time.Duration(p.Timeout) * time.Second,
+			Concurrency: p.Concurrency,
+		}
+	}
+	if m.probe != nil && m.probeOpts == opts {
+		return m.probe, nil
+	}
+	client, err := maintainer.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	if m.probe != nil {
+		m.probe.CloseIdleConnections()
+	}
+	m.probe, m.probeOpts = client, opts
+	return client, nil
+}
+
+// evaluateMaintainer returns why info should alarm under health, if at all.
+func evaluateMaintainer(info MaintainerInfo, epoch *big.Int, now time.Time, health *config.MaintainerHealth) []string {
+	var reasons []string
+	if health.MaxHeartbeatAge > 0 {
+		if info.LastHeartbeatTime == nil || info.LastHeartbeatTime.Sign() == 0 {
+			reasons = append(reasons, "no heartbeat recorded")
+		} else {
+			age := now.Sub(time.Unix(info.LastHeartbeatTime.Int64(), 0))
+			if age > time.Duration(health.MaxHeartbeatAge)*time.Second {
+				reasons = append(reasons, fmt.Sprintf("last heartbeat %s ago", age.Truncate(time.Second)))
+			}
+		}
+	}
+	if health.ActiveStatus != nil && info.Status != *health.ActiveStatus {
+		reasons = append(reasons, fmt.Sprintf("status=%d want %d", info.Status, *health.ActiveStatus))
+	}
+	if health.MaxEpochLag != nil && epoch != nil {
+		last := info.LastActiveEpoch
+		if last == nil {
+			last = new(big.Int)
+		}
+		lag := new(big.Int).Sub(epoch, last)
+		if lag.Cmp(new(big.Int).SetUint64(*health.MaxEpochLag)) > 0 {
+			reasons = append(reasons, fmt.Sprintf("lastActiveEpoch=%s lags epoch=%s by %s", last, epoch, lag))
+		}
+	}
+	return reasons
+}
+
+// evaluateProbe returns what the status endpoints of one maintainer report
+// wrong: an unanswered ping, P2P errors or no peers, and scanners trailing
+// their chain by scannerGap blocks or more.
+func evaluateProbe(res maintainer.Result, scannerGap int64) []string {
+	var reasons []string
+	if res.PingErr != nil {
+		reasons = append(reasons, fmt.Sprintf("unhealthy: %v", res.PingErr))
+	}
+	switch {
+	case res.P2PErr != nil:
+		reasons = append(reasons, fmt.Sprintf("failed to get P2P status: %v", res.P2PErr))
+	case res.P2P.Errors != nil:
+		reasons = append(reasons, fmt.Sprintf("P2P status errors=%v", res.P2P.Errors))
+	case len(res.P2P.Peers) == 0:
+		reasons = append(reasons, "P2P peerNode is empty")
+	}
+	if res.ScannerErr != nil {
+		reasons = append(reasons, fmt.Sprintf("failed to get scanner status: %v", res.ScannerErr))
+	}
+	chains := make([]string, 0, len(res.Scanner))
+	for chain := range res.Scanner {
+		chains = append(chains, chain)
+	}
+	sort.Strings(chains)
+	for _, chain := range chains {
+		v := res.Scanner[chain]
+		if v.ScannerHeightDiff >= scannerGap {
+			reasons = append(reasons, fmt.Sprintf("scanner height difference too high for %s chain: latest:%d, current:%d diff:%d",
+				chain, v.ChainHeight, v.BlockScannerHeight, v.ScannerHeightDiff))
+		}
+	}
+	return reasons
+}
+
+// formatMaintainerReport renders one line per unhealthy maintainer under a
+// header counting the healthy ones. ok is false when every maintainer is
+// healthy.
+func formatMaintainerReport(epoch *big.Int, healths []maintainerHealth) (string, bool) {
+	var lines []string
+	for _, h := range healths {
+		if len(h.Reasons) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("node(%s) ip=%s: %s", h.Info.Account.Hex(), h.Info.P2pAddress, strings.Join(h.Reasons, "; ")))
+	}
+	if len(lines) == 0 {
+		return "", false
+	}
+	header := fmt.Sprintf("TSS maintainer report, epoch=%s healthy=%d/%d", epoch, len(healths)-len(lines), len(healths))
+	return header + "\n" + strings.Join(lines, "\n"), true
+}
+
+// evaluateQuorum compares the maintainers whose status endpoints are all
+// fine with the signing threshold and returns the alarm to raise, listing
+// the failing nodes, when the healthy count is within the margin of the
+// threshold or below it.
+func evaluateQuorum(epoch *big.Int, healths []maintainerHealth, quorum *config.Quorum) (string, bool) {
+	healthy := 0
+	var failing []string
+	for _, h := range healths {
+		if h.Reachable {
+			healthy++
+			continue
+		}
+		failing = append(failing, fmt.Sprintf("node(%s) ip=%s", h.Info.Account.Hex(), h.Info.P2pAddress))
+	}
+	if healthy > quorum.AlarmAt() {
+		return "", false
+	}
+	state := "approaching quorum"
+	if healthy < quorum.Threshold {
+		state = "below quorum, TSS can not sign"
+	}
+	return fmt.Sprintf("TSS %s, epoch=%s healthy=%d/%d threshold=%d failing=[%s]",
+		state, epoch, healthy, len(healths), quorum.Threshold, strings.Join(failing, ", ")), true
+}
@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/maintainer"
+)
+
+func TestEvaluateMaintainer(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	active, lag := uint8(2), uint64(1)
+	health := &config.MaintainerHealth{MaxHeartbeatAge: 600, ActiveStatus: &active, MaxEpochLag: &lag}
+	healthy := MaintainerInfo{
+		Status:            2,
+		LastHeartbeatTime: big.NewInt(now.Add(-5 * time.Minute).Unix()),
+		LastActiveEpoch:   big.NewInt(9),
+	}
+	if reasons := evaluateMaintainer(healthy, big.NewInt(10), now, health); len(reasons) != 0 {
+		t.Fatalf("healthy maintainer alarmed: %v", reasons)
+	}
+
+	silent := MaintainerInfo{
+		Status:            3,
+		LastHeartbeatTime: big.NewInt(now.Add(-time.Hour).Unix()),
+		LastActiveEpoch:   big.NewInt(7),
+	}
+	reasons := evaluateMaintainer(silent, big.NewInt(10), now, health)
+	if len(reasons) != 3 || !strings.Contains(reasons[0], "1h0m0s") || !strings.Contains(reasons[2], "by 3") {
+		t.Fatalf("reasons = %v", reasons)
+	}
+
+	if reasons = evaluateMaintainer(silent, big.NewInt(10), now, &config.MaintainerHealth{}); len(reasons) != 0 {
+		t.Fatalf("unset thresholds alarmed: %v", reasons)
+	}
+	if reasons = evaluateMaintainer(MaintainerInfo{}, big.NewInt(1), now, &config.MaintainerHealth{MaxHeartbeatAge: 600}); len(reasons) != 1 {
+		t.Fatalf("missing heartbeat not reported: %v", reasons)
+	}
+}
+
+func TestEvaluateProbe(t *testing.T) {
+	healthy := maintainer.Result{
+		P2P:     &maintainer.P2PStatusResponse{Peers: []maintainer.P2PStatusPeer{{Address: "0x1"}}},
+		Scanner: maintainer.ScannerStatusResponse{"eth": {ChainHeight: 100, BlockScannerHeight: 95, ScannerHeightDiff: 5}},
+	}
+	if reasons := evaluateProbe(healthy, 10); len(reasons) != 0 {
+		t.Fatalf("healthy probe alarmed: %v", reasons)
+	}
+
+	lagging := healthy
+	lagging.P2P = &maintainer.P2PStatusResponse{}
+	lagging.Scanner = maintainer.ScannerStatusResponse{"eth": {ChainHeight: 100, BlockScannerHeight: 80, ScannerHeightDiff: 20}}
+	if reasons := evaluateProbe(lagging, 10); len(reasons) != 2 {
+		t.Fatalf("reasons = %v", reasons)
+	}
+
+	down := maintainer.Result{
+		PingErr:    errors.New("timeout"),
+		P2PErr:     errors.New("timeout"),
+		ScannerErr: errors.New("timeout"),
+	}
+	if reasons := evaluateProbe(down, 10); len(reasons) != 3 {
+		t.Fatalf("reasons = %v", reasons)
+	}
+}
+
+func TestFormatMaintainerReport(t *testing.T) {
+	healths := []maintainerHealth{
+		{Info: MaintainerInfo{Account: testHolder1, P2pAddress: "10.0.0.1"}},
+		{Info: MaintainerInfo{Account: testHolder2, P2pAddress: "10.0.0.2"}, Reasons: []string{"a", "b"}},
+	}
+	report, ok := formatMaintainerReport(big.NewInt(7), healths)
+	want := "TSS maintainer report, epoch=7 healthy=1/2\nnode(" + testHolder2.Hex() + ") ip=10.0.0.2: a; b"
+	if !ok || report != want {
+		t.Fatalf("report = %q", report)
+	}
+	if _, ok = formatMaintainerReport(big.NewInt(7), healths[:1]); ok {
+		t.Fatal("expected no report when every maintainer is healthy")
+	}
+}
+
+func TestEvaluateQuorum(t *testing.T) {
+	healths := []maintainerHealth{
+		{Info: MaintainerInfo{Account: testHolder1, P2pAddress: "10.0.0.1"}, Reachable: true},
+		{Info: MaintainerInfo{Account: testHolder2, P2pAddress: "10.0.0.2"}, Reachable: true},
+		{Info: MaintainerInfo{Account: testToken, P2pAddress: "10.0.0.3"}, Reachable: true},
+		{Info: MaintainerInfo{Account: testBadToken, P2pAddress: "10.0.0.4"}, Reasons: []string{"down"}},
+	}
+	quorum := &config.Quorum{Threshold: 2}
+	if msg, ok := evaluateQuorum(big.NewInt(3), healths, quorum); ok {
+		t.Fatalf("3 healthy of threshold 2 alarmed: %s", msg)
+	}
+	if _, ok := evaluateQuorum(big.NewInt(3), healths, &config.Quorum{Threshold: 2, Margin: 1}); !ok {
+		t.Fatal("expected an alarm within the margin")
+	}
+
+	healths[2].Reachable = false
+	msg, ok := evaluateQuorum(big.NewInt(3), healths, quorum)
+	if !ok || !strings.HasPrefix(msg, "TSS approaching quorum") || !strings.Contains(msg, "10.0.0.3") {
+		t.Fatalf("msg = %q", msg)
+	}
+
+	healths[1].Reachable = false
+	msg, ok = evaluateQuorum(big.NewInt(3), healths, quorum)
+	if !ok || !strings.HasPrefix(msg, "TSS below quorum") || !strings.Contains(msg, "healthy=1/4") {
+		t.Fatalf("msg = %q", msg)
+	}
+}
+
+func TestProbeClient_RebuiltOnProbeChange(t *testing.T) {
+	m := &Monitor{}
+	first, err := m.probeClient(&config.Tss{Probe: &config.MaintainerProbe{Port: 8080}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a reload builds a new Tss, the client stays while the probe is the same
+	same, err := m.probeClient(&config.Tss{Probe: &config.MaintainerProbe{Port: 8080}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same != first {
+		t.Fatal("probe client rebuilt for an unchanged probe")
+	}
+	changed, err := m.probeClient(&config.Tss{Probe: &config.MaintainerProbe{Port: 9090}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == first {
+		t.Fatal("probe client kept after the probe changed")
+	}
+}
@@ -0,0 +1,239 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/maintainer"
+	"github.com/mapprotocol/monitor/pkg/status"
+	"github.com/mapprotocol/monitor/pkg/util"
+)
+
+// Connectivity matrix cells, as rendered in alarms.
+const (
+	meshSelf     = "."
+	meshOpen     = "o"
+	meshClosed   = "x"
+	meshMissing  = "-"
+	meshMismatch = "!"
+	meshUnknown  = "?" // the viewer's P2P status could not be read
+)
+
+// MeshLink is what one maintainer reports about a peer.
+type MeshLink struct {
+	Cell     string `json:"cell"`
+	Status   string `json:"status,omitempty"`
+	PortOpen bool   `json:"portOpen"`
+	DialMs   int    `json:"dialMs"`
+}
+
+// Mesh is the P2P connectivity matrix of the maintainers of one epoch.
+// Links[i][j] is what Nodes[i] reports about Nodes[j].
+type Mesh struct {
+	Epoch  string       `json:"epoch"`
+	Nodes  []string     `json:"nodes"`
+	Links  [][]MeshLink `json:"links"`
+	Issues []string     `json:"issues"`
+}
+
+// meshCheck cross-references the P2P view of every maintainer, publishes
+// the connectivity matrix as the "mesh" status section and alarms with it
+// when the views disagree.
+func (m *Monitor) meshCheck(epoch *big.Int, infos []MaintainerInfo, results []maintainer.Result) {
+	mesh := analyzeMesh(epoch, infos, results, m.Cfg.Tss.Mesh)
+	status.Publish("mesh", mesh)
+	if len(mesh.Issues) == 0 {
+		m.Log.Info("P2P mesh consistent", "epoch", epoch, "maintainers", len(infos))
+		return
+	}
+	util.Alarm(context.Background(), fmt.Sprintf("P2P mesh inconsistent, epoch=%s\n%s\n%s",
+		epoch, strings.Join(mesh.Issues, "\n"), mesh.Render()))
+}
+
+// analyzeMesh builds the matrix and lists peer-ID mismatches, asymmetric
+// links, ports no maintainer can reach and dial latency outliers.
+func analyzeMesh(epoch *big.Int, infos []MaintainerInfo, results []maintainer.Result, cfg *config.MeshCheck) Mesh {
+	n := len(infos)
+	mesh := Mesh{Epoch: epoch.String(), Nodes: make([]string, n), Links: make([][]MeshLink, n)}
+	index := make(map[string]int, 2*n)
+	for i, info := range infos {
+		mesh.Nodes[i] = info.Account.Hex()
+		index[strings.ToLower(info.Account.Hex())] = i
+		if info.P2pAddress != "" {
+			index[info.P2pAddress] = i
+		}
+	}
+
+	// peer IDs each viewer has stored for a target, to compare across viewers
+	storedIDs := make([]map[string][]int, n)
+	for j := range storedIDs {
+		storedIDs[j] = make(map[string][]int)
+	}
+	var dials []int
+	for i := range infos {
+		row := make([]MeshLink, n)
+		for j := range row {
+			row[j] = MeshLink{Cell: meshMissing}
+		}
+		row[i] = MeshLink{Cell: meshSelf}
+		if results[i].P2P == nil {
+			for j := range row {
+				if j != i {
+					row[j].Cell = meshUnknown
+				}
+			}
+			mesh.Links[i] = row
+			continue
+		}
+		for _, peer := range results[i].P2P.Peers {
+			j, ok := index[strings.ToLower(peer.Address)]
+			if !ok {
+				j, ok = index[peer.IP]
+			}
+			if !ok || j == i {
+				continue
+			}
+			link := MeshLink{Cell: meshOpen, Status: peer.Status, PortOpen: peer.P2PPortOpen, DialMs: peer.P2PDialMs}
+			if !peer.P2PPortOpen {
+				link.Cell = meshClosed
+			} else {
+				dials = append(dials, peer.P2PDialMs)
+			}
+			if ids := distinct(peer.StoredPeerID, peer.NodesPeerID, peer.ReturnedPeerID); len(ids) > 1 {
+				link.Cell = meshMismatch
+				mesh.Issues = append(mesh.Issues, fmt.Sprintf("peer id mismatch: %s sees %s stored=%s nodes=%s returned=%s",
+					short(mesh.Nodes[i]), short(mesh.Nodes[j]), peer.StoredPeerID, peer.NodesPeerID, peer.ReturnedPeerID))
+			}
+			if peer.StoredPeerID != "" {
+				storedIDs[j][peer.StoredPeerID] = append(storedIDs[j][peer.StoredPeerID], i)
+			}
+			row[j] = link
+		}
+		mesh.Links[i] = row
+	}
+
+	for j := range infos {
+		if len(storedIDs[j]) > 1 {
+			ids := make([]string, 0, len(storedIDs[j]))
+			for id := range storedIDs[j] {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			mesh.Issues = append(mesh.Issues, fmt.Sprintf("peer id mismatch: maintainers store %d ids for %s: %s",
+				len(ids), short(mesh.Nodes[j]), strings.Join(ids, ",")))
+		}
+	}
+
+	unreachable := make([]bool, n)
+	for j := range infos {
+		viewers, closed := 0, 0
+		for i := range infos {
+			if i == j || mesh.Links[i][j].Cell == meshUnknown {
+				continue
+			}
+			viewers++
+			if mesh.Links[i][j].Cell == meshClosed || mesh.Links[i][j].Cell == meshMissing {
+				closed++
+			}
+		}
+		if viewers > 0 && closed == viewers {
+			unreachable[j] = true
+			mesh.Issues = append(mesh.Issues, fmt.Sprintf("port closed: no maintainer reaches %s (%s)", short(mesh.Nodes[j]), infos[j].P2pAddress))
+		}
+	}
+
+	for i := range infos {
+		for j := i + 1; j < n; j++ {
+			a, b := mesh.Links[i][j], mesh.Links[j][i]
+			// a node nobody reaches is already reported once
+			if a.Cell == meshUnknown || b.Cell == meshUnknown || unreachable[i] || unreachable[j] {
+				continue
+			}
+			if reaches(a) != reaches(b) {
+				from, to := i, j
+				if reaches(b) {
+					from, to = j, i
+				}
+				mesh.Issues = append(mesh.Issues, fmt.Sprintf("asymmetric link: %s reaches %s but not the reverse",
+					short(mesh.Nodes[from]), short(mesh.Nodes[to])))
+			}
+		}
+	}
+
+	if limit := latencyLimit(dials, cfg); limit > 0 {
+		for i := range infos {
+			for j := range infos {
+				if l := mesh.Links[i][j]; reaches(l) && l.DialMs > limit {
+					mesh.Issues = append(mesh.Issues, fmt.Sprintf("dial latency outlier: %s to %s %dms, limit %dms",
+						short(mesh.Nodes[i]), short(mesh.Nodes[j]), l.DialMs, limit))
+				}
+			}
+		}
+	}
+	return mesh
+}
+
+// latencyLimit is the dial time above which a link is an outlier: Factor
+// times the median dial, and never less than MinOutlierMs.
+func latencyLimit(dials []int, cfg *config.MeshCheck) int {
+	if len(dials) < 3 {
+		return 0
+	}
+	factor, floor := config.DefaultMeshLatencyFactor, config.DefaultMeshMinOutlierMs
+	if cfg != nil {
+		if cfg.LatencyFactor > 0 {
+			factor = cfg.LatencyFactor
+		}
+		if cfg.MinOutlierMs > 0 {
+			floor = cfg.MinOutlierMs
+		}
+	}
+	sorted := append([]int(nil), dials...)
+	sort.Ints(sorted)
+	median := sorted[len(sorted)/2]
+	return max(int(float64(median)*factor), floor)
+}
+
+func reaches(l MeshLink) bool {
+	return l.Cell == meshOpen || l.Cell == meshMismatch
+}
+
+func distinct(ids ...string) map[string]bool {
+	set := make(map[string]bool)
+	for _, id := range ids {
+		if id != "" {
+			set[id] = true
+		}
+	}
+	return set
+}
+
+// short abbreviates an address for the matrix legend and issues.
+func short(addr string) string {
+	if len(addr) <= 10 {
+		return addr
+	}
+	return addr[:6] + ".." + addr[len(addr)-4:]
+}
+
+// Render draws the matrix with one row per viewer: o reachable, x port
+// closed, - not in the viewer's peers, ! peer id mismatch, ? unknown.
+func (mesh Mesh) Render() string {
+	var b strings.Builder
+	b.WriteString("   ")
+	for j := range mesh.Nodes {
+		fmt.Fprintf(&b, " %2d", j)
+	}
+	for i, row := range mesh.Links {
+		fmt.Fprintf(&b, "\n%2d ", i)
+		for _, l := range row {
+			fmt.Fprintf(&b, "  %s", l.Cell)
+		}
+		fmt.Fprintf(&b, "  %s", short(mesh.Nodes[i]))
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mapprotocol/monitor/pkg/maintainer"
+)
+
+func meshNodes() []MaintainerInfo {
+	infos := make([]MaintainerInfo, 4)
+	for i := range infos {
+		infos[i] = MaintainerInfo{Account: common.BigToAddress(big.NewInt(int64(i + 1))), P2pAddress: "10.0.0." + string(rune('1'+i))}
+	}
+	return infos
+}
+
+// fullMesh has every maintainer reach every other at 20ms with matching
+// peer ids.
+func fullMesh(infos []MaintainerInfo) []maintainer.Result {
+	results := make([]maintainer.Result, len(infos))
+	for i := range infos {
+		status := &maintainer.P2PStatusResponse{}
+		for j, peer := range infos {
+			if i == j {
+				continue
+			}
+			id := "peer-" + peer.P2pAddress
+			status.Peers = append(status.Peers, maintainer.P2PStatusPeer{
+				Address: peer.Account.Hex(), IP: peer.P2pAddress, Status: "connected",
+				StoredPeerID: id, NodesPeerID: id, ReturnedPeerID: id, P2PPortOpen: true, P2PDialMs: 20,
+			})
+		}
+		results[i].P2P = status
+	}
+	return results
+}
+
+func TestAnalyzeMesh_Consistent(t *testing.T) {
+	infos := meshNodes()
+	mesh := analyzeMesh(big.NewInt(5), infos, fullMesh(infos), nil)
+	if len(mesh.Issues) != 0 {
+		t.Fatalf("issues = %v", mesh.Issues)
+	}
+	if mesh.Links[0][0].Cell != meshSelf || mesh.Links[0][1].Cell != meshOpen {
+		t.Fatalf("links = %+v", mesh.Links[0])
+	}
+}
+
+func TestAnalyzeMesh_Issues(t *testing.T) {
+	infos := meshNodes()
+	results := fullMesh(infos)
+	// node 0 stores a stale id for node 1
+	results[0].P2P.Peers[0].StoredPeerID = "stale"
+	// node 2 drops node 1 from its peers: asymmetric
+	results[2].P2P.Peers = append(results[2].P2P.Peers[:1], results[2].P2P.Peers[2:]...)
+	// nobody reaches node 3
+	for i := 0; i < 3; i++ {
+		for k := range results[i].P2P.Peers {
+			if results[i].P2P.Peers[k].IP == infos[3].P2pAddress {
+				results[i].P2P.Peers[k].P2PPortOpen = false
+			}
+		}
+	}
+	// node 1 dials node 0 slowly
+	results[1].P2P.Peers[0].P2PDialMs = 900
+
+	mesh := analyzeMesh(big.NewInt(5), infos, results, nil)
+	want := []string{"peer id mismatch: 0x0000..0001 sees 0x0000..0002", "store 2 ids for 0x0000..0002",
+		"port closed: no maintainer reaches 0x0000..0004", "asymmetric link: 0x0000..0002 reaches 0x0000..0003",
+		"dial latency outlier: 0x0000..0002 to 0x0000..0001 900ms"}
+	if len(mesh.Issues) != len(want) {
+		t.Fatalf("issues = %q", mesh.Issues)
+	}
+	for i, w := range want {
+		if !strings.Contains(mesh.Issues[i], w) {
+			t.Fatalf("issue %d = %q, want %q", i, mesh.Issues[i], w)
+		}
+	}
+	if got := mesh.Render(); !strings.Contains(got, " 0   .  !  o  x") {
+		t.Fatalf("render =\n%s", got)
+	}
+}
+
+func TestAnalyzeMesh_UnknownViewer(t *testing.T) {
+	infos := meshNodes()
+	results := fullMesh(infos)
+	results[2].P2P = nil
+	mesh := analyzeMesh(big.NewInt(5), infos, results, nil)
+	if len(mesh.Issues) != 0 || mesh.Links[2][0].Cell != meshUnknown {
+		t.Fatalf("issues = %v links = %+v", mesh.Issues, mesh.Links[2])
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"io"
 	"math/big"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -22,27 +21,55 @@ import (
 	"github.com/mapprotocol/monitor/internal/chain"
 	"github.com/mapprotocol/monitor/internal/config"
 	"github.com/mapprotocol/monitor/internal/mapprotocol"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/blockstore"
+	"github.com/mapprotocol/monitor/pkg/maintainer"
 	"github.com/mapprotocol/monitor/pkg/mempool"
+	"github.com/mapprotocol/monitor/pkg/price"
+	"github.com/mapprotocol/monitor/pkg/tokenmeta"
 	"github.com/mapprotocol/monitor/pkg/util"
+	"github.com/shopspring/decimal"
 )
 
-var dece = big.NewInt(1000000000000000000)
+// nativeDecimals is the number of wei digits in one native EVM coin.
+const nativeDecimals = config.EvmDecimals
 
 type Monitor struct {
 	*chain.Common
 	heightCount           int64
 	balance, syncedHeight *big.Int
 	timestamp             int64
-	balMapping            map[string]float64
+	balMapping            map[string]amount.Amount
+	multicall             map[common.Address]bool // whether Multicall3 is deployed at the address
+	tokens                *tokenmeta.Cache
+	nonces                map[common.Address]*nonceState
+	epoch                 epochState
+	probe                 *maintainer.Client
+	probeOpts             maintainer.Options       // the options probe was built with
+	crossSources          map[string]crossTxSource // by the settings each was built from
+	txStore               *blockstore.TxStore
+	txStorePath           string                        // the Tss.StorePath txStore was opened at
+	outflows              map[balanceKey]*outflowWindow // only touched by the block scanner
 }
 
 func New(cs *chain.Common) *Monitor {
-	return &Monitor{
+	m := &Monitor{
 		Common:       cs,
 		balance:      new(big.Int),
 		syncedHeight: new(big.Int),
-		balMapping:   make(map[string]float64),
+		balMapping:   make(map[string]amount.Amount),
+		multicall:    make(map[common.Address]bool),
+		nonces:       make(map[common.Address]*nonceState),
+		outflows:     make(map[balanceKey]*outflowWindow),
 	}
+	m.tokens = tokenmeta.NewCache(m.callToken, cs.Log)
+	return m
+}
+
+// callToken makes a read-only call of data against token.
+func (m *Monitor) callToken(token string, data []byte) ([]byte, error) {
+	to := common.HexToAddress(token)
+	return m.Conn.Client().CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: data}, nil)
 }
 
 func (m *Monitor) Sync() error {
@@ -59,14 +86,15 @@ func (m *Monitor) Sync() error {
 }
 
 // prepareTick takes a Snapshot of the live OptConfig and parses the chain-
-// level WaterLine. It returns ok=false when WaterLine is malformed so the
-// caller can shut the chain down. It is invoked at the top of every poll
-// iteration so reconfigs (waterLine / users / from / contractToken) take
-// effect on the next tick without restarting the goroutine.
+// level WaterLine. It returns ok=false when WaterLine is missing, malformed
+// or a dollar amount without a price, so the caller can skip the chain-level
+// balance checks. It is invoked at the top of every poll iteration so
+// reconfigs (waterLine / users / from / contractToken) take effect on the
+// next tick without restarting the goroutine.
 func (m *Monitor) prepareTick() (config.OptConfig, *big.Int, bool) {
 	snap := m.Snapshot()
-	wl, ok := config.ParseNativeWaterLine(snap.WaterLine, 18)
-	if !ok {
+	wl, err := price.WaterLine(snap.WaterLine, snap.Symbol, nativeDecimals)
+	if err != nil {
 		return snap, nil, false
 	}
 	return snap, wl, true
@@ -80,42 +108,45 @@ func (m *Monitor) sync() error {
 		default:
 			snap, waterLine, ok := m.prepareTick()
 			if !ok {
-				m.SysErr <- fmt.Errorf("%s waterLine Not Number", snap.Name)
-				return nil
+				m.Log.Error("Chain waterLine invalid, skip from balance check", "waterLine", snap.WaterLine)
 			}
 
 			// rebuild balMapping each tick so user add/remove reload takes effect
-			m.balMapping = make(map[string]float64)
+			m.balMapping = make(map[string]amount.Amount)
 			for _, from := range snap.From {
-				m.balMapping[from] = 0
+				m.balMapping[from] = amount.Amount{}
 			}
 			for _, user := range snap.Users {
 				for _, from := range strings.Split(user.From, ",") {
-					m.balMapping[from] = 0
+					m.balMapping[from] = amount.Amount{}
 				}
 			}
 
+			balances := m.fetchBalances(snap.Multicall, tickBalanceKeys(snap, waterLine != nil || snap.Gas.MinRelays > 0))
+
 			for _, ele := range snap.From {
-				if ele == "" {
+				if ele == "" || waterLine == nil {
 					continue
 				}
-				m.checkBalance(common.HexToAddress(ele), waterLine, "unknown", false)
+				m.checkBalance(common.HexToAddress(ele), balances, waterLine, "unknown", false)
 			}
 
 			for _, user := range snap.Users {
-				wl, ok := config.ParseNativeWaterLine(user.WaterLine, 18)
-				if !ok {
-					m.SysErr <- fmt.Errorf("%s waterLine Not Number", snap.Name)
-					return nil
+				wl, err := price.WaterLine(user.WaterLine, snap.Symbol, nativeDecimals)
+				if err != nil {
+					m.Log.Error("User waterLine invalid, skip balance check", "group", user.Group, "waterLine", user.WaterLine, "err", err)
+					continue
 				}
 				for _, from := range strings.Split(user.From, ",") {
-					m.checkBalance(common.HexToAddress(from), wl, user.Group, false)
+					m.checkBalance(common.HexToAddress(from), balances, wl, user.Group, false)
 				}
 			}
 
 			for _, ct := range snap.ContractToken {
-				m.checkToken(common.HexToAddress(ct.Address), ct.Tokens)
+				m.checkToken(common.HexToAddress(ct.Address), ct.Tokens, balances)
 			}
+			m.nonceCheck(snap)
+			m.gasCheck(snap, balances)
 
 			if snap.Id == snap.MapChainID {
 				m.mapCheck()
@@ -133,8 +164,8 @@ func (m *Monitor) reportUser() {
 		return
 	}
 	var (
-		now                = make(map[string]float64)
-		yesTotal, nowTotal = float64(0), float64(0)
+		now                = make(map[string]amount.Amount)
+		yesTotal, nowTotal amount.Amount
 	)
 
 	for addr, yesHave := range m.balMapping {
@@ -148,28 +179,27 @@ func (m *Monitor) reportUser() {
 			return
 		}
 
-		bal := float64(new(big.Int).Div(balance, config.Wei).Int64()) / float64(config.Wei.Int64())
+		bal := amount.New(balance, nativeDecimals)
 		now[addr] = bal
-		nowTotal += bal
-		yesTotal += yesHave
+		nowTotal = nowTotal.Add(bal)
+		yesTotal = yesTotal.Add(yesHave)
 	}
 
 	//if time.Now().Unix()-m.timestamp > 86400 {
 	//	util.Alarm(context.Background(),
-	//		fmt.Sprintf("Report balance detail,chains=%s,yesterday=%0.4f,now=%0.4f",
+	//		fmt.Sprintf("Report balance detail,chains=%s,yesterday=%s,now=%s",
 	//			m.Cfg.Name, yesTotal, nowTotal))
 	//}
 
+	m.Log.Debug("Report balance detail", "yesterday", yesTotal, "now", nowTotal)
 	m.timestamp = time.Now().Unix()
-	m.balMapping = make(map[string]float64)
 	m.balMapping = now
 }
 
-func (m *Monitor) checkBalance(addr common.Address, waterLine *big.Int, group string, report bool) {
-	balance, err := m.Conn.Client().BalanceAt(context.Background(), addr, nil)
+func (m *Monitor) checkBalance(addr common.Address, balances balanceSet, waterLine *big.Int, group string, report bool) {
+	balance, err := balances.get(balanceKey{Holder: addr})
 	if err != nil {
 		m.Log.Error("Unable to get user balance failed", "from", addr, "err", err)
-		time.Sleep(config.RetryLongInterval)
 		return
 	}
 
@@ -177,98 +207,56 @@ func (m *Monitor) checkBalance(addr common.Address, waterLine *big.Int, group st
 		m.balance = balance
 	}
 
-	wl := float64(new(big.Int).Div(waterLine, config.Wei).Int64()) / float64(config.Wei.Int64())
-	bal := float64(new(big.Int).Div(balance, config.Wei).Int64()) / float64(config.Wei.Int64())
-	m.Log.Info("Get balance result", "account", addr, "balance", bal, "wl", wl, "balance", balance)
-	if balance.Cmp(waterLine) == -1 {
+	wl := amount.New(waterLine, nativeDecimals)
+	bal := amount.New(balance, nativeDecimals)
+	m.Log.Info("Get balance result", "account", addr, "balance", bal, "wl", wl)
+	if bal.LessThan(wl) {
 		// alarm
 		util.Alarm(context.Background(),
-			fmt.Sprintf("Balance Less than %0.4f Balance,chains=%s group=%s addr=%s balance=%0.4f", wl, m.Cfg.Name, group, addr, bal))
+			fmt.Sprintf("Balance Less than %s Balance,chains=%s group=%s addr=%s balance=%s%s", wl, m.Cfg.Name, group, addr, bal, price.Describe(m.Snapshot().Symbol, bal)))
 	}
 
 	now := time.Now().UTC()
 	if report && now.Weekday() == time.Monday && now.Hour() == 11 && now.Minute() == 10 {
 		util.Alarm(context.Background(),
-			fmt.Sprintf("Report Address Balance have,chains=%s addr=%s balance=%0.4f,waterLine=%0.4f", m.Cfg.Name, addr, bal, wl))
+			fmt.Sprintf("Report Address Balance have,chains=%s addr=%s balance=%s%s,waterLine=%s", m.Cfg.Name, addr, bal,
+				price.Describe(m.Snapshot().Symbol, bal), wl))
 	}
 }
 
-func (m *Monitor) checkToken(contract common.Address, tokens []config.EthToken) {
+func (m *Monitor) checkToken(contract common.Address, tokens []config.EthToken, balances balanceSet) {
 	for _, tk := range tokens {
 		ad := common.HexToAddress(tk.Addr)
-		input, err := mapprotocol.TokenAbi.PackInput("balanceOf", contract)
-		if err != nil {
-			continue
-		}
-		outPut, err := m.Conn.Client().CallContract(context.Background(),
-			ethereum.CallMsg{To: &ad, Data: input}, nil)
+		ret, err := balances.get(balanceKey{Token: ad, Holder: contract})
 		if err != nil {
-			m.Log.Error("CheckToken callContract failed", "err", err.Error(), "to", ad)
+			m.Log.Error("CheckToken balanceOf failed", "err", err.Error(), "to", ad)
 			continue
 		}
 
-		var ret *big.Int
-		if err = mapprotocol.TokenAbi.UnpackOutput("balanceOf", &ret, outPut); err != nil {
-			m.Log.Error("CheckToken unpack failed", "err", err.Error())
+		meta := m.tokens.Resolve(tk)
+		overage := amount.New(ret, meta.Decimals)
+		wl, err := amount.FromFloat(tk.WaterLine, meta.Decimals)
+		if err != nil {
+			m.Log.Error("CheckToken waterLine invalid", "token", tk.Name, "err", err)
 			continue
 		}
-
-		wei := tk.Wei
-		if wei == 0 {
-			wei = 18
-		}
-
-		retF, _ := ret.Float64()
-		overage, _ := big.NewFloat(0).Quo(big.NewFloat(retF), util.ToWeiFloat(int64(1), int(wei))).Float64()
-		m.Log.Info("Get Token result", "token", tk.Name, "contract", contract, "overage", overage, "addr", tk.Addr)
-		if overage < tk.WaterLine {
+		m.Log.Info("Get Token result", "token", tk.Name, "symbol", meta.Symbol, "contract", contract, "overage", overage, "addr", tk.Addr)
+		if overage.LessThan(wl) {
 			// alarm
 			util.Alarm(context.Background(),
-				fmt.Sprintf("Token Less than %0.4f,chains=%s token=%s addr=%s overage=%0.4f ", tk.WaterLine, m.Cfg.Name, tk.Name, contract, overage))
+				fmt.Sprintf("Token Less than %s,chains=%s token=%s addr=%s overage=%s ", wl, m.Cfg.Name, tk.Name, contract, overage))
 		}
 	}
 }
 
 func (m *Monitor) mapCheck() {
-	for idx, contract := range m.Cfg.Tk.Contracts {
-		if m.Cfg.Tk.Token[idx] == "btc" {
-			m.nativeCheck(contract)
-			continue
-		}
-		contractAmount, err := mapprotocol.TotalSupply(contract)
-		if err != nil {
-			m.Log.Error("Check brc20 balance, get amount by contract", "token", m.Cfg.Tk.Token[idx], "err", err)
-			continue
-		}
-		contractAmount = contractAmount.Div(contractAmount, dece)
-
-		lockAmount, err := mapprotocol.BalanceOf(contract, common.HexToAddress(m.Cfg.Tk.MapBridge))
-		if err != nil {
-			m.Log.Error("Check brc20 balance, get lock amount by contract", "token", m.Cfg.Tk.Token[idx], "err", err)
-			continue
-		}
-		lockAmount = lockAmount.Div(lockAmount, dece)
-
-		afterBridgeBal, err := GetMulAddBalance(m.Cfg.Genni.Endpoint, m.Cfg.Genni.Key, m.Cfg.Tk.BridgeAddr, m.Cfg.Tk.Token[idx])
-		if err != nil {
-			m.Log.Error("Check brc20 balance, get amount by genii", "token", m.Cfg.Tk.Token[idx], "err", err)
-			continue
-		}
-		if m.Cfg.Tk.Token[idx] == "roup" {
-			afterBridgeBal = afterBridgeBal + 900000
-		}
-		m.Log.Info("Check brc20 balance, get amount", "token", m.Cfg.Tk.Token[idx], "bridgeBal", afterBridgeBal,
-			"contractAmount", contractAmount, "lockAmount", lockAmount)
-		if afterBridgeBal < (contractAmount.Int64() - lockAmount.Int64()) {
-			util.Alarm(context.Background(), fmt.Sprintf("check brc20 balance token=%s, bridgeBal=%d, contractAmount=%v",
-				m.Cfg.Tk.Token[idx], afterBridgeBal, contractAmount))
-		}
-		time.Sleep(time.Second)
-	}
+	m.reconcileCheck()
 
 	if m.Cfg.Tss != nil {
 		m.tssCheck()
 		m.crossTxCheck()
+		m.pendingOutflowCheck()
+		m.utxoCheck()
 	}
 }
 
@@ -315,6 +303,7 @@ func (m *Monitor) tssCheck() {
 		m.Log.Error("failed to call contract", "method", "getEpochInfo", "err", err)
 		return
 	}
+	m.epochCheck(epoch, epochInfo.Info)
 
 	// get maintainer info
 	type Back struct {
@@ -325,151 +314,7 @@ func (m *Monitor) tssCheck() {
 		m.Log.Error("failed to call contract", "method", "getMaintainerInfos", "err", err)
 		return
 	}
-	m.checkNodeHealth(ret.Infos)
-	m.checkScanner(ret.Infos)
-	m.checkP2pStatus(ret.Infos)
-}
-
-func (m *Monitor) checkNodeHealth(infos []MaintainerInfo) {
-	for _, info := range infos {
-		url := fmt.Sprintf("http://%s:6040/ping", info.P2pAddress)
-
-		resp, err := http.Get(url)
-		if err != nil {
-			m.Log.Error("failed to get node health", "address", info.P2pAddress, "err", err)
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			util.Alarm(context.Background(), fmt.Sprintf("node(%s) is unhealthy ", info.Account.Hex()))
-		}
-	}
-}
-
-func (m *Monitor) checkP2pStatus(infos []MaintainerInfo) {
-	for _, info := range infos {
-		p2pStatus, err := m.GetP2PStatus(info.P2pAddress)
-		if err != nil {
-			util.Alarm(context.Background(),
-				fmt.Sprintf("failed to get P2P status, address=%s ip=%s, err=%s", info.Account, info.P2pAddress, err))
-			continue
-		}
-		if p2pStatus == nil {
-			continue
-		}
-		if p2pStatus.Errors != nil {
-			util.Alarm(context.Background(),
-				fmt.Sprintf("P2P status error, address=%s ip=%s, errors=%s", info.Account, info.P2pAddress, p2pStatus.Errors))
-			continue
-		}
-		if len(p2pStatus.Peers) == 0 {
-			util.Alarm(context.Background(),
-				fmt.Sprintf("P2P peerNode is empty, address=%s ip=%s", info.Account, info.P2pAddress))
-			continue
-		}
-		m.Log.Info("P2P status", "address", info.P2pAddress, "status", p2pStatus)
-	}
-}
-
-type P2PStatusPeer struct {
-	Address        string `json:"address"`
-	IP             string `json:"ip"`
-	Status         string `json:"status"`
-	StoredPeerID   string `json:"stored_peer_id"`
-	NodesPeerID    string `json:"nodes_peer_id"`
-	ReturnedPeerID string `json:"returned_peer_id"`
-	P2PPortOpen    bool   `json:"p2p_port_open"`
-	P2PDialMs      int    `json:"p2p_dial_ms"`
-}
-
-// P2PStatusResponse represents the response from /status/p2p endpoint
-type P2PStatusResponse struct {
-	Peers     []P2PStatusPeer `json:"peers"`
-	PeerCount int             `json:"peer_count"`
-	Errors    interface{}     `json:"errors"`
-}
-
-// GetP2PStatus fetches P2P status from the given IP address
-func (m *Monitor) GetP2PStatus(ipAddress string) (*P2PStatusResponse, error) {
-	url := fmt.Sprintf("http://%s:6040/status/p2p", ipAddress)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to request P2P status: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	var statusResponse P2PStatusResponse
-	if err := json.Unmarshal(body, &statusResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-
-	return &statusResponse, nil
-}
-
-func (m *Monitor) checkScanner(infos []MaintainerInfo) {
-	for _, info := range infos {
-		scanner, err := m.GetScannerStatus(info.P2pAddress)
-		if err != nil {
-			util.Alarm(context.Background(),
-				fmt.Sprintf("failed to node(%s) get scanner status for node :%v",
-					info.Account.Hex(), err.Error()))
-			continue
-		}
-		for k, v := range scanner {
-			if v.ScannerHeightDiff < m.Cfg.Tss.ScannerGap {
-				continue
-			}
-			util.Alarm(context.Background(),
-				fmt.Sprintf("node(%s) scanner height difference too high for %s chain: latest:%d, current:%d diff:%d",
-					info.Account.Hex(), k, v.ChainHeight, v.BlockScannerHeight, v.ScannerHeightDiff))
-		}
-	}
-}
-
-type ScannerStatus struct {
-	Chain              string `json:"chain"`
-	ChainHeight        int64  `json:"chain_height"`
-	BlockScannerHeight int64  `json:"block_scanner_height"`
-	ScannerHeightDiff  int64  `json:"scanner_height_diff"`
-}
-
-// ScannerStatusResponse represents the response from /status/scanner endpoint
-type ScannerStatusResponse map[string]ScannerStatus
-
-// GetScannerStatus fetches scanner status from the given IP address
-func (m *Monitor) GetScannerStatus(ipAddress string) (ScannerStatusResponse, error) {
-	url := fmt.Sprintf("http://%s:6040/status/scanner", ipAddress)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to request scanner status: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	var statusResponse ScannerStatusResponse
-	if err := json.Unmarshal(body, &statusResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-
-	return statusResponse, nil
+	m.maintainerReport(epoch, ret.Infos)
 }
 
 func (m *Monitor) callContract(ret interface{}, addr, method string, abiInst *mapoabi.Abi, params ...interface{}) error {
@@ -486,28 +331,44 @@ func (m *Monitor) callContract(ret interface{}, addr, method string, abiInst *ma
 	return abiInst.UnpackOutput(method, ret, output)
 }
 
-func (m *Monitor) nativeCheck(contract string) {
-	de := big.NewInt(10000000000)
-	first := strings.Split(m.Cfg.Tk.BtcBridgeAddr, ",")[0]
-	btcSrcAfter, err := getBtcBalanceByMem(first)
-	if err != nil {
-		m.Log.Error("Native check  ", "addr", first, "err ", err)
+// pendingOutflowCheck alarms when unconfirmed transactions move more than
+// Tss.PendingOutflowWaterLine out of the TSS BTC address.
+func (m *Monitor) pendingOutflowCheck() {
+	tss := m.Cfg.Tss
+	if tss == nil || tss.BtcAddress == "" || tss.PendingOutflowWaterLine == "" {
+		return
+	}
+	waterLine, ok := config.ParseNativeWaterLine(tss.PendingOutflowWaterLine, 8)
+	if !ok {
+		m.Log.Error("Pending outflow check, waterLine Not Number", "waterLine", tss.PendingOutflowWaterLine)
 		return
 	}
-	m.Log.Info("Native check ", "total", btcSrcAfter)
 
-	ret := mapprotocol.MinterCapResp{}
-	err = mapprotocol.Call(contract, mapprotocol.MinterCapMethod, common.HexToAddress(m.Cfg.Tk.MapBridge), &ret)
+	esplora := tssEsplora(tss)
+	if esplora == nil {
+		esplora = m.Cfg.Tk.Esplora
+	}
+	balances, err := getBtcBalanceByMem(esplora, tss.BtcAddress)
 	if err != nil {
-		m.Log.Error("Native check, get amount by map contract", "err", err)
+		m.Log.Error("Pending outflow check, get balance failed", "addr", tss.BtcAddress, "err", err)
 		return
 	}
-	contractAmount := ret.Total.Div(ret.Total, de)
-	m.Log.Info("Check Native BTC balance, get amount", "bridgeBal", btcSrcAfter, "contractAmount", contractAmount)
-	if btcSrcAfter < (contractAmount.Int64()) {
-		util.Alarm(context.Background(), fmt.Sprintf("check brc20 balance token=btc, bridgeBal=%d, contractAmount=%v", btcSrcAfter, contractAmount))
+	m.Log.Info("Pending outflow check", "addr", tss.BtcAddress, "confirmed", balances.Confirmed,
+		"pending", balances.Pending, "effective", balances.Effective)
+	if msg := pendingOutflowAlarm(tss.BtcAddress, balances, waterLine.Int64()); msg != "" {
+		util.Alarm(context.Background(), msg)
 	}
-	time.Sleep(time.Second)
+}
+
+// pendingOutflowAlarm returns the alarm for balances, or "" while the net
+// unconfirmed outflow stays within waterLine satoshis.
+func pendingOutflowAlarm(addr string, balances *mempool.Balances, waterLine int64) string {
+	outflow := balances.PendingOutflow()
+	if outflow <= waterLine {
+		return ""
+	}
+	return fmt.Sprintf("BTC pending outflow more than %s BTC,addr=%s pending=%s confirmed=%s effective=%s",
+		btcAmount(waterLine), addr, btcAmount(-outflow), btcAmount(balances.Confirmed), btcAmount(balances.Effective))
 }
 
 func (m *Monitor) OtherChainCheck() {
@@ -533,55 +394,55 @@ func (m *Monitor) OtherChainCheck() {
 	}
 }
 
-func GetMulAddBalance(endpoint, key, bridge, token string) (int64, error) {
-	var ret int64
+func GetMulAddBalance(endpoint, key, bridge, token string) (decimal.Decimal, error) {
+	ret := decimal.Zero
 	for _, b := range strings.Split(bridge, ",") {
 		afterBridgeBal, err := TokenBalanceGD(endpoint, key, b, token)
 		if err != nil {
-			return 0, err
+			return decimal.Zero, err
 		}
-		ret += afterBridgeBal
+		ret = ret.Add(afterBridgeBal)
 	}
 
 	return ret, nil
 }
 
-func TokenBalanceGD(endpoint, key, address, token string) (int64, error) {
+func TokenBalanceGD(endpoint, key, address, token string) (decimal.Decimal, error) {
 	path := fmt.Sprintf("/api/1/brc20/balance?address=%s&tick=%s&limit=1&offset=0", address, token)
 	url := fmt.Sprintf("%s%s", endpoint, path)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return 0, fmt.Errorf("assamble req failed, err is %v", err)
+		return decimal.Zero, fmt.Errorf("assamble req failed, err is %v", err)
 	}
 	req.Header.Set("api-key", key)
 
 	r, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("do req failed, err is %v", err)
+		return decimal.Zero, fmt.Errorf("do req failed, err is %v", err)
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		return 0, fmt.Errorf("io.ReadAll failed, err is %v", err)
+		return decimal.Zero, fmt.Errorf("io.ReadAll failed, err is %v", err)
 	}
 	_ = r.Body.Close()
 
 	ret := &gdTokenBalanceResponse{}
 	if err = json.Unmarshal(body, ret); err != nil {
-		return 0, err
+		return decimal.Zero, err
 	}
 	if ret.Code != 0 || ret.Message != "success" {
-		return 0, fmt.Errorf("failed to get token balance, code: %v, msg: %s", ret.Code, ret.Message)
+		return decimal.Zero, fmt.Errorf("failed to get token balance, code: %v, msg: %s", ret.Code, ret.Message)
 	}
 
 	if len(ret.Data.List) == 0 || ret.Data.List[0].OverallBalance == "" {
-		return 0, nil
+		return decimal.Zero, nil
 	}
-	balance, err := strconv.ParseFloat(ret.Data.List[0].OverallBalance, 64)
+	balance, err := decimal.NewFromString(ret.Data.List[0].OverallBalance)
 	if err != nil {
-		return 0, fmt.Errorf("strconv.ParseFloat failed, err is %v", err)
+		return decimal.Zero, fmt.Errorf("genii balance %q not number", ret.Data.List[0].OverallBalance)
 	}
-	return int64(balance), nil
+	return balance, nil
 }
 
 type gdTokenBalanceResponse struct {
@@ -598,15 +459,41 @@ type gdTokenBalanceResponse struct {
 	} `json:"data"`
 }
 
-func getBtcBalanceByMem(bridgeAddr string) (int64, error) {
-	netParams := &chaincfg.MainNetParams
-	client := mempool.NewClient(netParams)
-	address, _ := btcutil.DecodeAddress(bridgeAddr, netParams)
-	b, err := client.GetBalance(address)
+// newEsploraClient builds a BTC client from cfg; a nil cfg selects the
+// public mempool.space mainnet API.
+func newEsploraClient(cfg *config.Esplora) (*mempool.MempoolClient, error) {
+	if cfg == nil {
+		return mempool.NewClient(&chaincfg.MainNetParams)
+	}
+	netParams, err := mempool.ParseNetwork(cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+	return mempool.New(mempool.Options{
+		BaseURL:       cfg.Url,
+		Network:       netParams,
+		Timeout:       time.Duration(cfg.Timeout) * time.Second,
+		Retries:       cfg.Retries,
+		RetryInterval: time.Duration(cfg.RetryInterval) * time.Second,
+		AuthHeader:    cfg.AuthHeader,
+		AuthValue:     cfg.AuthValue,
+	})
+}
+
+func getBtcBalanceByMem(cfg *config.Esplora, bridgeAddr string) (*mempool.Balances, error) {
+	client, err := newEsploraClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	address, err := btcutil.DecodeAddress(bridgeAddr, client.NetParams())
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode btc address %s", bridgeAddr)
+	}
+	b, err := client.GetBalances(address)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	log.Info("get res by mem", "balance", b)
+	log.Info("get res by mem", "balance", b.Confirmed, "pending", b.Pending)
 
 	return b, nil
 }
@@ -30,8 +30,8 @@ func TestPrepareTick_ReadsLatestWaterLine(t *testing.T) {
 }
 
 // TestPrepareTick_InvalidWaterLineReturnsNotOk: a malformed WaterLine should
-// surface as ok=false so the polling loop can shut the chain down with a
-// SysErr — same behaviour as the pre-refactor code on first parse.
+// surface as ok=false so the polling loop skips the chain-level balance
+// checks for that tick.
 func TestPrepareTick_InvalidWaterLineReturnsNotOk(t *testing.T) {
 	cfg := &config.OptConfig{Name: "t", WaterLine: "not-a-number"}
 	cs := chain.NewCommonSync(nil, cfg, nil, nil, nil)
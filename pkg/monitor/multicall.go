@@ -0,0 +1,233 @@
+package monitor
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/internal/mapprotocol"
+)
+
+// balanceBatchSize bounds the calls sent in one aggregate3 call or one
+// JSON-RPC batch.
+const balanceBatchSize = 200
+
+const multicall3Json = `[
+	{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"internalType":"address","name":"addr","type":"address"}],"name":"getEthBalance","outputs":[{"internalType":"uint256","name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+var multicall3Abi, _ = abi.JSON(strings.NewReader(multicall3Json))
+
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type result3 struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// balanceKey is one balance read: the native coin of Holder when Token is
+// the zero address, else Token.balanceOf(Holder).
+type balanceKey struct {
+	Token  common.Address
+	Holder common.Address
+}
+
+type balanceResult struct {
+	Value *big.Int
+	Err   error
+}
+
+// balanceSet holds the balances read in one tick.
+type balanceSet map[balanceKey]balanceResult
+
+func (s balanceSet) get(key balanceKey) (*big.Int, error) {
+	res, ok := s[key]
+	if !ok {
+		return nil, errors.Errorf("balance of %s not read", key.Holder)
+	}
+	return res.Value, res.Err
+}
+
+// tickBalanceKeys lists every balance sync reads in a tick: the native
+// balance of From (when it is checked) and of every user, and every
+// configured token of every contractToken holder.
+func tickBalanceKeys(snap config.OptConfig, withFrom bool) []balanceKey {
+	var keys []balanceKey
+	if withFrom {
+		for _, from := range snap.From {
+			if from != "" {
+				keys = append(keys, balanceKey{Holder: common.HexToAddress(from)})
+			}
+		}
+	}
+	for _, user := range snap.Users {
+		for _, from := range strings.Split(user.From, ",") {
+			keys = append(keys, balanceKey{Holder: common.HexToAddress(from)})
+		}
+	}
+	for _, ct := range snap.ContractToken {
+		for _, tk := range ct.Tokens {
+			keys = append(keys, balanceKey{Token: common.HexToAddress(tk.Addr), Holder: common.HexToAddress(ct.Address)})
+		}
+	}
+	return keys
+}
+
+// fetchBalances reads keys through Multicall3 when it is deployed at the
+// chain's multicall address, otherwise through JSON-RPC batch requests.
+func (m *Monitor) fetchBalances(multicall common.Address, keys []balanceKey) balanceSet {
+	ctx := context.Background()
+	client := m.Conn.Client()
+	if multicall != (common.Address{}) && m.hasMulticall(ctx, client, multicall) {
+		set, err := multicallBalances(ctx, client, multicall, keys)
+		if err == nil {
+			return set
+		}
+		m.Log.Warn("Multicall3 balance batch failed, fall back to JSON-RPC batch", "multicall", multicall, "err", err)
+	}
+	set, err := rpcBatchBalances(ctx, client.Client(), keys)
+	if err != nil {
+		m.Log.Error("JSON-RPC balance batch failed", "err", err)
+	}
+	return set
+}
+
+// hasMulticall reports whether code is deployed at addr, remembering the
+// answer so the check is made once per address.
+func (m *Monitor) hasMulticall(ctx context.Context, client *ethclient.Client, addr common.Address) bool {
+	if deployed, ok := m.multicall[addr]; ok {
+		return deployed
+	}
+	code, err := client.CodeAt(ctx, addr, nil)
+	if err != nil {
+		m.Log.Warn("Check Multicall3 code failed", "multicall", addr, "err", err)
+		return false
+	}
+	m.multicall[addr] = len(code) > 0
+	if len(code) == 0 {
+		m.Log.Warn("Multicall3 not deployed, use JSON-RPC batch", "multicall", addr)
+	}
+	return len(code) > 0
+}
+
+func multicallBalances(ctx context.Context, client *ethclient.Client, multicall common.Address, keys []balanceKey) (balanceSet, error) {
+	set := make(balanceSet, len(keys))
+	for start := 0; start < len(keys); start += balanceBatchSize {
+		chunk := keys[start:min(start+balanceBatchSize, len(keys))]
+		calls := make([]call3, 0, len(chunk))
+		for _, key := range chunk {
+			call, err := balanceCall3(multicall, key)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, call)
+		}
+		input, err := multicall3Abi.Pack("aggregate3", calls)
+		if err != nil {
+			return nil, err
+		}
+		output, err := client.CallContract(ctx, ethereum.CallMsg{To: &multicall, Data: input}, nil)
+		if err != nil {
+			return nil, err
+		}
+		results, err := unpackAggregate3(output)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) != len(chunk) {
+			return nil, errors.Errorf("aggregate3 returned %d results for %d calls", len(results), len(chunk))
+		}
+		for i, key := range chunk {
+			if !results[i].Success {
+				set[key] = balanceResult{Err: errors.Errorf("balance call reverted, token=%s holder=%s", key.Token, key.Holder)}
+				continue
+			}
+			set[key] = decodeUint256(results[i].ReturnData)
+		}
+	}
+	return set, nil
+}
+
+func balanceCall3(multicall common.Address, key balanceKey) (call3, error) {
+	if key.Token == (common.Address{}) {
+		data, err := multicall3Abi.Pack("getEthBalance", key.Holder)
+		return call3{Target: multicall, AllowFailure: true, CallData: data}, err
+	}
+	data, err := mapprotocol.TokenAbi.PackInput("balanceOf", key.Holder)
+	return call3{Target: key.Token, AllowFailure: true, CallData: data}, err
+}
+
+func unpackAggregate3(output []byte) ([]result3, error) {
+	values, err := multicall3Abi.Unpack("aggregate3", output)
+	if err != nil {
+		return nil, err
+	}
+	results := *abi.ConvertType(values[0], new([]result3)).(*[]result3)
+	return results, nil
+}
+
+func decodeUint256(data []byte) balanceResult {
+	if len(data) < 32 {
+		return balanceResult{Err: errors.Errorf("balance call returned %d bytes", len(data))}
+	}
+	return balanceResult{Value: new(big.Int).SetBytes(data[:32])}
+}
+
+// rpcBatchBalances reads keys with eth_getBalance and eth_call, sent as
+// JSON-RPC batches. A failed batch marks all of its keys failed.
+func rpcBatchBalances(ctx context.Context, client *rpc.Client, keys []balanceKey) (balanceSet, error) {
+	set := make(balanceSet, len(keys))
+	var lastErr error
+	for start := 0; start < len(keys); start += balanceBatchSize {
+		chunk := keys[start:min(start+balanceBatchSize, len(keys))]
+		elems := make([]rpc.BatchElem, len(chunk))
+		for i, key := range chunk {
+			if key.Token == (common.Address{}) {
+				elems[i] = rpc.BatchElem{Method: "eth_getBalance", Args: []interface{}{key.Holder, "latest"}, Result: new(hexutil.Big)}
+				continue
+			}
+			data, err := mapprotocol.TokenAbi.PackInput("balanceOf", key.Holder)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = rpc.BatchElem{
+				Method: "eth_call",
+				Args:   []interface{}{map[string]interface{}{"to": key.Token, "data": hexutil.Bytes(data)}, "latest"},
+				Result: new(hexutil.Bytes),
+			}
+		}
+		if err := client.BatchCallContext(ctx, elems); err != nil {
+			lastErr = err
+			for _, key := range chunk {
+				set[key] = balanceResult{Err: err}
+			}
+			continue
+		}
+		for i, key := range chunk {
+			if elems[i].Error != nil {
+				set[key] = balanceResult{Err: elems[i].Error}
+				continue
+			}
+			switch result := elems[i].Result.(type) {
+			case *hexutil.Big:
+				set[key] = balanceResult{Value: result.ToInt()}
+			case *hexutil.Bytes:
+				set[key] = decodeUint256(*result)
+			}
+		}
+	}
+	return set, lastErr
+}
@@ -0,0 +1,297 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChainSafe/log15"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/mapprotocol/monitor/internal/chain"
+	"github.com/mapprotocol/monitor/internal/config"
+)
+
+var (
+	testMulticall = config.DefaultMulticall3
+	testToken     = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	testBadToken  = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	testHolder1   = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	testHolder2   = common.HexToAddress("0x0000000000000000000000000000000000000002")
+)
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// fakeChain answers the JSON-RPC calls fetchBalances makes from fixed
+// native and token balances, and records what it was asked.
+type fakeChain struct {
+	code     string
+	native   map[common.Address]int64
+	tokens   map[common.Address]int64     // testToken balances; testBadToken reverts
+	nonces   map[common.Address][2]uint64 // latest and pending nonce
+	baseFee  *big.Int                     // nil for a pre-London chain
+	gasPrice int64
+	tip      int64
+	head     uint64
+	logs     []types.Log // eth_getLogs answers every filter with these
+	blockTxs map[uint64][]rpcTx
+	reverted map[common.Hash]bool
+	traces   []rpcTrace // trace_filter answers every filter with these
+	pruned   bool       // state is only served at latest, as on a pruned node
+	requests int
+	methods  []string
+}
+
+func (f *fakeChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests++
+	body, _ := io.ReadAll(r.Body)
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		var reqs []rpcRequest
+		_ = json.Unmarshal(body, &reqs)
+		resps := make([]map[string]interface{}, 0, len(reqs))
+		for _, req := range reqs {
+			resps = append(resps, f.answer(req))
+		}
+		_ = json.NewEncoder(w).Encode(resps)
+		return
+	}
+	var req rpcRequest
+	_ = json.Unmarshal(body, &req)
+	_ = json.NewEncoder(w).Encode(f.answer(req))
+}
+
+func (f *fakeChain) answer(req rpcRequest) map[string]interface{} {
+	f.methods = append(f.methods, req.Method)
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_getCode":
+		resp["result"] = f.code
+	case "eth_getTransactionCount":
+		var holder common.Address
+		var tag string
+		_ = json.Unmarshal(req.Params[0], &holder)
+		_ = json.Unmarshal(req.Params[1], &tag)
+		nonce := f.nonces[holder][0]
+		if tag == "pending" {
+			nonce = f.nonces[holder][1]
+		}
+		resp["result"] = hexutil.Uint64(nonce)
+	case "eth_getBlockByNumber":
+		var full bool
+		if len(req.Params) > 1 {
+			_ = json.Unmarshal(req.Params[1], &full)
+		}
+		if !full {
+			resp["result"] = &types.Header{Number: big.NewInt(1), Difficulty: new(big.Int), BaseFee: f.baseFee}
+			return resp
+		}
+		var number hexutil.Uint64
+		_ = json.Unmarshal(req.Params[0], &number)
+		txs := f.blockTxs[uint64(number)]
+		if txs == nil {
+			txs = []rpcTx{}
+		}
+		resp["result"] = rpcBlock{Number: number, Timestamp: number * 10, Transactions: txs}
+	case "eth_getTransactionReceipt":
+		var hash common.Hash
+		_ = json.Unmarshal(req.Params[0], &hash)
+		status := hexutil.Uint64(types.ReceiptStatusSuccessful)
+		if f.reverted[hash] {
+			status = hexutil.Uint64(types.ReceiptStatusFailed)
+		}
+		resp["result"] = rpcReceipt{Status: status}
+	case "trace_filter":
+		resp["result"] = f.traces
+	case "eth_blockNumber":
+		resp["result"] = hexutil.Uint64(f.head)
+	case "eth_getLogs":
+		resp["result"] = f.logs
+	case "eth_gasPrice":
+		resp["result"] = hexutil.EncodeBig(big.NewInt(f.gasPrice))
+	case "eth_maxPriorityFeePerGas":
+		resp["result"] = hexutil.EncodeBig(big.NewInt(f.tip))
+	case "eth_getBalance":
+		if f.pruned && !latest(req.Params[1]) {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": "missing trie node"}
+			return resp
+		}
+		var holder common.Address
+		_ = json.Unmarshal(req.Params[0], &holder)
+		resp["result"] = hexutil.EncodeBig(big.NewInt(f.native[holder]))
+	case "eth_call":
+		if f.pruned && !latest(req.Params[1]) {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": "missing trie node"}
+			return resp
+		}
+		var msg struct {
+			To    common.Address `json:"to"`
+			Input hexutil.Bytes  `json:"input"`
+			Data  hexutil.Bytes  `json:"data"`
+		}
+		_ = json.Unmarshal(req.Params[0], &msg)
+		data := msg.Input
+		if len(data) == 0 {
+			data = msg.Data
+		}
+		if msg.To == testMulticall {
+			resp["result"] = hexutil.Bytes(f.aggregate3(data))
+			return resp
+		}
+		ok, ret := f.call(msg.To, data)
+		if !ok {
+			resp["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+			return resp
+		}
+		resp["result"] = hexutil.Bytes(ret)
+	default:
+		resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	}
+	return resp
+}
+
+// latest reports whether the block parameter of a state call is "latest".
+func latest(param json.RawMessage) bool {
+	var tag string
+	return json.Unmarshal(param, &tag) == nil && tag == "latest"
+}
+
+func (f *fakeChain) call(to common.Address, data []byte) (bool, []byte) {
+	holder := common.BytesToAddress(data[4:36])
+	switch to {
+	case testMulticall:
+		return true, common.LeftPadBytes(big.NewInt(f.native[holder]).Bytes(), 32)
+	case testToken:
+		return true, common.LeftPadBytes(big.NewInt(f.tokens[holder]).Bytes(), 32)
+	default:
+		return false, nil
+	}
+}
+
+func (f *fakeChain) aggregate3(input []byte) []byte {
+	method := multicall3Abi.Methods["aggregate3"]
+	values, err := method.Inputs.Unpack(input[4:])
+	if err != nil {
+		panic(err)
+	}
+	calls := *abi.ConvertType(values[0], new([]call3)).(*[]call3)
+	results := make([]result3, 0, len(calls))
+	for _, c := range calls {
+		ok, ret := f.call(c.Target, c.CallData)
+		results = append(results, result3{Success: ok, ReturnData: ret})
+	}
+	out, err := method.Outputs.Pack(results)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+type fakeConn struct {
+	chain.Connection
+	client *ethclient.Client
+}
+
+func (c *fakeConn) Client() *ethclient.Client { return c.client }
+
+func newFakeMonitor(t *testing.T, f *fakeChain) *Monitor {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client, err := ethclient.Dial(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := chain.NewCommonSync(&fakeConn{client: client}, &config.OptConfig{Name: "t"}, log15.New(), nil, nil)
+	return New(cs)
+}
+
+func testKeys() []balanceKey {
+	return []balanceKey{
+		{Holder: testHolder1},
+		{Holder: testHolder2},
+		{Token: testToken, Holder: testHolder1},
+		{Token: testBadToken, Holder: testHolder1},
+	}
+}
+
+func checkTestBalances(t *testing.T, set balanceSet) {
+	t.Helper()
+	want := map[balanceKey]int64{
+		{Holder: testHolder1}:                   100,
+		{Holder: testHolder2}:                   200,
+		{Token: testToken, Holder: testHolder1}: 300,
+	}
+	for key, value := range want {
+		got, err := set.get(key)
+		if err != nil || got.Int64() != value {
+			t.Fatalf("balance %v = %v, %v; want %d", key, got, err, value)
+		}
+	}
+	if _, err := set.get(balanceKey{Token: testBadToken, Holder: testHolder1}); err == nil {
+		t.Fatal("expected the reverting token to fail")
+	}
+}
+
+func TestFetchBalances_Multicall(t *testing.T) {
+	f := &fakeChain{
+		code:   "0x6080",
+		native: map[common.Address]int64{testHolder1: 100, testHolder2: 200},
+		tokens: map[common.Address]int64{testHolder1: 300},
+	}
+	m := newFakeMonitor(t, f)
+
+	checkTestBalances(t, m.fetchBalances(testMulticall, testKeys()))
+	checkTestBalances(t, m.fetchBalances(testMulticall, testKeys()))
+	// one eth_getCode, then one aggregate3 call per tick
+	if f.requests != 3 {
+		t.Fatalf("expected 3 requests, got %d: %v", f.requests, f.methods)
+	}
+}
+
+func TestFetchBalances_FallbackToRpcBatch(t *testing.T) {
+	f := &fakeChain{
+		code:   "0x",
+		native: map[common.Address]int64{testHolder1: 100, testHolder2: 200},
+		tokens: map[common.Address]int64{testHolder1: 300},
+	}
+	m := newFakeMonitor(t, f)
+
+	checkTestBalances(t, m.fetchBalances(testMulticall, testKeys()))
+	// one eth_getCode, then a single batch holding every read
+	if f.requests != 2 || len(f.methods) != 5 {
+		t.Fatalf("expected 2 requests and 5 calls, got %d: %v", f.requests, f.methods)
+	}
+
+	// multicall "none" skips the code check entirely
+	f.requests, f.methods = 0, nil
+	checkTestBalances(t, m.fetchBalances(common.Address{}, testKeys()))
+	if f.requests != 1 {
+		t.Fatalf("expected one batch request, got %d: %v", f.requests, f.methods)
+	}
+}
+
+func TestTickBalanceKeys(t *testing.T) {
+	snap := config.OptConfig{
+		From:  []string{testHolder1.Hex()},
+		Users: []config.From{{Group: "g", From: testHolder2.Hex()}},
+		ContractToken: []config.ContractToken{
+			{Address: testHolder1.Hex(), Tokens: []config.EthToken{{Name: "t", Addr: testToken.Hex()}}},
+		},
+	}
+	if keys := tickBalanceKeys(snap, true); len(keys) != 3 {
+		t.Fatalf("keys with from = %v", keys)
+	}
+	if keys := tickBalanceKeys(snap, false); len(keys) != 2 || keys[0].Holder != testHolder2 {
+		t.Fatalf("keys without from = %v", keys)
+	}
+}
@@ -0,0 +1,165 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/util"
+)
+
+type nonces struct {
+	Confirmed uint64 // NonceAt latest
+	Pending   uint64 // PendingNonceAt
+	Err       error
+}
+
+// nonceState is what is remembered about one sender between ticks.
+type nonceState struct {
+	Confirmed      uint64
+	ConfirmedSince time.Time // when Confirmed last changed
+	PendingSince   time.Time // when pending first exceeded confirmed, zero while it does not
+	GapSince       time.Time // when the gap reached the threshold, zero while below it
+}
+
+// nonceCheck alarms on senders whose pending transactions are stuck behind
+// a nonce that does not confirm.
+func (m *Monitor) nonceCheck(snap config.OptConfig) {
+	addrs := senderAddresses(snap)
+	if len(addrs) == 0 || (snap.Nonce.GapAfter == 0 && snap.Nonce.StallAfter == 0) {
+		return
+	}
+	results, err := rpcBatchNonces(context.Background(), m.Conn.Client().Client(), addrs)
+	if err != nil {
+		m.Log.Error("Nonce check failed", "err", err)
+		return
+	}
+	now := time.Now()
+	seen := make(map[common.Address]bool, len(addrs))
+	for _, addr := range addrs {
+		seen[addr] = true
+		res := results[addr]
+		if res.Err != nil {
+			m.Log.Error("Nonce check failed", "addr", addr, "err", res.Err)
+			continue
+		}
+		state := m.nonces[addr]
+		if state == nil {
+			state = &nonceState{Confirmed: res.Confirmed, ConfirmedSince: now}
+			m.nonces[addr] = state
+		}
+		m.Log.Info("Nonce check", "addr", addr, "confirmed", res.Confirmed, "pending", res.Pending)
+		for _, reason := range evaluateNonce(state, res, now, snap.Nonce) {
+			util.Alarm(context.Background(), fmt.Sprintf("Nonce stuck,chains=%s addr=%s confirmed=%d pending=%d, %s",
+				snap.Name, addr, res.Confirmed, res.Pending, reason))
+		}
+	}
+	for addr := range m.nonces {
+		if !seen[addr] {
+			delete(m.nonces, addr)
+		}
+	}
+}
+
+// evaluateNonce folds a new reading into state and returns why it should
+// alarm, if at all.
+func evaluateNonce(state *nonceState, res nonces, now time.Time, cfg config.NonceCheck) []string {
+	if res.Confirmed != state.Confirmed {
+		state.Confirmed, state.ConfirmedSince = res.Confirmed, now
+	}
+	var gap uint64
+	if res.Pending > res.Confirmed {
+		gap = res.Pending - res.Confirmed
+	}
+	if gap == 0 {
+		state.PendingSince = time.Time{}
+	} else if state.PendingSince.IsZero() {
+		state.PendingSince = now
+	}
+	if gap == 0 || gap < cfg.Gap {
+		state.GapSince = time.Time{}
+	} else if state.GapSince.IsZero() {
+		state.GapSince = now
+	}
+
+	var reasons []string
+	if cfg.GapAfter > 0 && !state.GapSince.IsZero() && now.Sub(state.GapSince) >= cfg.GapAfter {
+		reasons = append(reasons, fmt.Sprintf("gap %d has lasted %s", gap, now.Sub(state.GapSince).Truncate(time.Second)))
+	}
+	// an idle sender's first transaction is not stalled from the start
+	stalled := state.ConfirmedSince
+	if state.PendingSince.After(stalled) {
+		stalled = state.PendingSince
+	}
+	if cfg.StallAfter > 0 && gap > 0 && now.Sub(stalled) >= cfg.StallAfter {
+		reasons = append(reasons, fmt.Sprintf("confirmed nonce unchanged for %s with %d pending",
+			now.Sub(stalled).Truncate(time.Second), gap))
+	}
+	return reasons
+}
+
+// senderAddresses lists the distinct From and Users addresses of snap.
+func senderAddresses(snap config.OptConfig) []common.Address {
+	var addrs []common.Address
+	seen := make(map[common.Address]bool)
+	add := func(s string) {
+		s = strings.TrimSpace(s)
+		if s == "" || !common.IsHexAddress(s) {
+			return
+		}
+		addr := common.HexToAddress(s)
+		if addr == config.ZeroAddress || seen[addr] {
+			return
+		}
+		seen[addr] = true
+		addrs = append(addrs, addr)
+	}
+	for _, from := range snap.From {
+		add(from)
+	}
+	for _, user := range snap.Users {
+		for _, from := range strings.Split(user.From, ",") {
+			add(from)
+		}
+	}
+	return addrs
+}
+
+// rpcBatchNonces reads the latest and pending nonce of every address in
+// JSON-RPC batches.
+func rpcBatchNonces(ctx context.Context, client *rpc.Client, addrs []common.Address) (map[common.Address]nonces, error) {
+	results := make(map[common.Address]nonces, len(addrs))
+	for start := 0; start < len(addrs); start += balanceBatchSize / 2 {
+		chunk := addrs[start:min(start+balanceBatchSize/2, len(addrs))]
+		elems := make([]rpc.BatchElem, 0, 2*len(chunk))
+		for _, addr := range chunk {
+			elems = append(elems,
+				rpc.BatchElem{Method: "eth_getTransactionCount", Args: []interface{}{addr, "latest"}, Result: new(hexutil.Uint64)},
+				rpc.BatchElem{Method: "eth_getTransactionCount", Args: []interface{}{addr, "pending"}, Result: new(hexutil.Uint64)},
+			)
+		}
+		if err := client.BatchCallContext(ctx, elems); err != nil {
+			return nil, err
+		}
+		for i, addr := range chunk {
+			latest, pending := elems[2*i], elems[2*i+1]
+			switch {
+			case latest.Error != nil:
+				results[addr] = nonces{Err: latest.Error}
+			case pending.Error != nil:
+				results[addr] = nonces{Err: pending.Error}
+			default:
+				results[addr] = nonces{
+					Confirmed: uint64(*latest.Result.(*hexutil.Uint64)),
+					Pending:   uint64(*pending.Result.(*hexutil.Uint64)),
+				}
+			}
+		}
+	}
+	return results, nil
+}
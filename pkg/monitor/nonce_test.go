@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mapprotocol/monitor/internal/config"
+)
+
+func TestEvaluateNonce(t *testing.T) {
+	cfg := config.NonceCheck{Gap: 2, GapAfter: 10 * time.Minute, StallAfter: 5 * time.Minute}
+	start := time.Unix(1700000000, 0)
+	state := &nonceState{Confirmed: 10, ConfirmedSince: start}
+
+	steps := []struct {
+		after     time.Duration
+		confirmed uint64
+		pending   uint64
+		alarms    int
+	}{
+		{after: 0, confirmed: 10, pending: 11, alarms: 0},                // one pending, below gap
+		{after: 4 * time.Minute, confirmed: 10, pending: 12, alarms: 0},  // gap starts
+		{after: 6 * time.Minute, confirmed: 10, pending: 12, alarms: 1},  // stalled
+		{after: 7 * time.Minute, confirmed: 11, pending: 13, alarms: 0},  // confirmed moves
+		{after: 14 * time.Minute, confirmed: 12, pending: 14, alarms: 1}, // gap has lasted 10m
+		{after: 15 * time.Minute, confirmed: 14, pending: 14, alarms: 0}, // queue drained
+		{after: 30 * time.Minute, confirmed: 14, pending: 14, alarms: 0}, // idle is fine
+	}
+	for i, step := range steps {
+		reasons := evaluateNonce(state, nonces{Confirmed: step.confirmed, Pending: step.pending}, start.Add(step.after), cfg)
+		if len(reasons) != step.alarms {
+			t.Fatalf("step %d: reasons %v, want %d", i, reasons, step.alarms)
+		}
+	}
+
+	// idle for an hour, then one transaction: the stall counts from the send
+	idle := &nonceState{Confirmed: 20, ConfirmedSince: start}
+	sent := start.Add(time.Hour)
+	if reasons := evaluateNonce(idle, nonces{Confirmed: 20, Pending: 21}, sent, cfg); len(reasons) != 0 {
+		t.Fatalf("first pending after idle alarmed: %v", reasons)
+	}
+	if reasons := evaluateNonce(idle, nonces{Confirmed: 20, Pending: 21}, sent.Add(4*time.Minute), cfg); len(reasons) != 0 {
+		t.Fatalf("pending for 4m alarmed: %v", reasons)
+	}
+	reasons := evaluateNonce(idle, nonces{Confirmed: 20, Pending: 21}, sent.Add(5*time.Minute), cfg)
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "unchanged for 5m0s") {
+		t.Fatalf("pending for 5m: reasons %v", reasons)
+	}
+	if evaluateNonce(idle, nonces{Confirmed: 21, Pending: 21}, sent.Add(6*time.Minute), cfg); !idle.PendingSince.IsZero() {
+		t.Fatalf("PendingSince = %v after the gap closed", idle.PendingSince)
+	}
+
+	off := config.NonceCheck{Gap: 1}
+	stuck := &nonceState{Confirmed: 1, ConfirmedSince: start}
+	if reasons := evaluateNonce(stuck, nonces{Confirmed: 1, Pending: 5}, start.Add(time.Hour), off); len(reasons) != 0 {
+		t.Fatalf("disabled checks alarmed: %v", reasons)
+	}
+}
+
+func TestSenderAddresses(t *testing.T) {
+	snap := config.OptConfig{
+		From:  []string{testHolder1.Hex(), "", config.ZeroAddress.Hex()},
+		Users: []config.From{{Group: "g", From: testHolder2.Hex() + "," + testHolder1.Hex()}},
+	}
+	addrs := senderAddresses(snap)
+	if len(addrs) != 2 || addrs[0] != testHolder1 || addrs[1] != testHolder2 {
+		t.Fatalf("senderAddresses = %v", addrs)
+	}
+}
+
+func TestRpcBatchNonces(t *testing.T) {
+	f := &fakeChain{nonces: map[common.Address][2]uint64{testHolder1: {5, 7}, testHolder2: {3, 3}}}
+	m := newFakeMonitor(t, f)
+
+	got, err := rpcBatchNonces(context.Background(), m.Conn.Client().Client(), []common.Address{testHolder1, testHolder2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[testHolder1].Confirmed != 5 || got[testHolder1].Pending != 7 || got[testHolder2].Pending != 3 {
+		t.Fatalf("nonces = %+v", got)
+	}
+	if f.requests != 1 {
+		t.Fatalf("expected one batch request, got %d", f.requests)
+	}
+}
@@ -8,87 +8,92 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math/big"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/shopspring/decimal"
 )
 
-func getBalanceByOk(endpoint, key, bridge, project, passphrase, secrectKey, token string) (int64, error) {
+// getBalanceByOk sums the BRC-20 balance of token over the comma separated
+// bridge addresses. Credential sets are tried in order until one answers for
+// every address.
+func getBalanceByOk(endpoint, key, bridge, project, passphrase, secrectKey, token string) (decimal.Decimal, error) {
 	addrs := strings.Split(bridge, ",")
 	keys := strings.Split(key, ",")
 	passphrases := strings.Split(passphrase, ",")
 	secrectKeys := strings.Split(secrectKey, ",")
+	if len(passphrases) != len(keys) || len(secrectKeys) != len(keys) {
+		return decimal.Zero, errors.New("okx key, passphrase and secretKey counts differ")
+	}
+
+	var lastErr error
 	for idx, key := range keys {
-		total := big.NewInt(0)
+		total := decimal.Zero
+		failed := false
 		for _, b := range addrs {
-			urlPath := fmt.Sprintf("/api/v5/explorer/brc20/address-balance-list?address=%s&token=%v", b, token)
-			req, err := http.NewRequest(http.MethodGet, endpoint+urlPath, nil)
-			if err != nil {
-				continue
-			}
-			timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
-			req.Header.Set("OK-ACCESS-KEY", key)
-			data := HmacSha256ToBase64(secrectKeys[idx], timestamp+"GET"+urlPath)
-			req.Header.Set("Ok-Access-Sign", data)
-			req.Header.Set("OK-ACCESS-PASSPHRASE", passphrases[idx])
-			req.Header.Set("OK-ACCESS-PROJECT", project)
-			req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
-			r, err := http.DefaultClient.Do(req)
+			bal, err := okAddressBalance(endpoint, key, passphrases[idx], secrectKeys[idx], project, b, token)
 			if err != nil {
-				log.Info("do req failed", "err", err, "key", key)
-				continue
+				log.Info("get bal by ok failed", "err", err, "key", key, "addr", b)
+				lastErr = err
+				failed = true
+				break
 			}
+			total = total.Add(bal)
+		}
+		if !failed {
+			return total, nil
+		}
+	}
 
-			body, err := io.ReadAll(r.Body)
-			if err != nil {
-				log.Info("io.ReadAll failed", "err", err, "key", key)
-				continue
-			}
-			_ = r.Body.Close()
+	return decimal.Zero, fmt.Errorf("okx balance failed with every key: %w", lastErr)
+}
 
-			resp := balanceListResp{}
-			err = json.Unmarshal(body, &resp)
-			if err != nil {
-				log.Info("json.Unmarshal failed", "err", err, "key", key)
-				continue
-			}
+func okAddressBalance(endpoint, key, passphrase, secrectKey, project, address, token string) (decimal.Decimal, error) {
+	urlPath := fmt.Sprintf("/api/v5/explorer/brc20/address-balance-list?address=%s&token=%v", address, token)
+	req, err := http.NewRequest(http.MethodGet, endpoint+urlPath, nil)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	req.Header.Set("OK-ACCESS-KEY", key)
+	req.Header.Set("Ok-Access-Sign", HmacSha256ToBase64(secrectKey, timestamp+"GET"+urlPath))
+	req.Header.Set("OK-ACCESS-PASSPHRASE", passphrase)
+	req.Header.Set("OK-ACCESS-PROJECT", project)
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("do req failed, err is %v", err)
+	}
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("io.ReadAll failed, err is %v", err)
+	}
+	if r.StatusCode != http.StatusOK {
+		return decimal.Zero, fmt.Errorf("okx returned %d: %s", r.StatusCode, string(body))
+	}
 
-			log.Info("get bal result by ok", "key", key, "result", string(body))
-			if resp.Code != "0" {
-				log.Info("resp code not success", "code", resp.Code, "msg", resp.Msg, "key", key)
-				continue
-			}
+	resp := balanceListResp{}
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return decimal.Zero, err
+	}
+	if resp.Code != "0" {
+		return decimal.Zero, fmt.Errorf("okx resp code not success, code: %s, msg: %s", resp.Code, resp.Msg)
+	}
 
-			isFailed := false
-			bridgeBal := big.NewInt(0)
-			for _, v := range resp.Data {
-				for _, ele := range v.BalanceList {
-					bal, ok := big.NewInt(0).SetString(ele.Balance, 10)
-					if !ok {
-						log.Info("balane get faileld", "backBal", ele.Balance, "key", key)
-						isFailed = true
-						break
-					}
-					bridgeBal = bridgeBal.Add(bridgeBal, bal)
-				}
-				if isFailed {
-					break
-				}
-			}
-			if isFailed {
-				log.Info("balane get faileld, continue", "key", key)
-				continue
+	bridgeBal := decimal.Zero
+	for _, v := range resp.Data {
+		for _, ele := range v.BalanceList {
+			bal, err := decimal.NewFromString(ele.Balance)
+			if err != nil {
+				return decimal.Zero, fmt.Errorf("okx balance %q not number", ele.Balance)
 			}
-			total = total.Add(total, bridgeBal)
+			bridgeBal = bridgeBal.Add(bal)
 		}
-
-		return total.Int64(), nil
 	}
-
-	return 0, errors.New("failed")
+	return bridgeBal, nil
 }
 
 type Balance struct {
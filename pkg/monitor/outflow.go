@@ -0,0 +1,460 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/util"
+)
+
+// transferTopic is the topic0 of the ERC-20 Transfer event.
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// outflowCatchUpBatches is how many batches a range may end behind head
+// before its window check is skipped: the balances are only served at head.
+const outflowCatchUpBatches = 3
+
+// outflowLimit holds the outflow thresholds of one holder and token (the
+// zero address for the native coin), amounts in the smallest unit.
+type outflowLimit struct {
+	Name     string
+	Decimals int32
+	Max      *big.Int      // a single outflow above it alarms, nil disables
+	Window   time.Duration // 0 disables the window alarm
+	Percent  float64       // of the balance before the window's outflows
+	Traces   bool          // native transfers are read with trace_filter
+}
+
+// outflow is value that left a holder: one ERC-20 Transfer or one native
+// transfer.
+type outflow struct {
+	Key    balanceKey
+	Amount *big.Int
+	Tx     common.Hash
+	Block  uint64
+	Time   int64
+}
+
+// outflowRange is what readOutflows found in blocks [From, To].
+type outflowRange struct {
+	From, To uint64
+	Time     int64      // timestamp of block To
+	Outflows []outflow  // in block order
+	Balances balanceSet // at head, of the keys with a window
+	Behind   bool       // To is too far behind head to read Balances
+}
+
+// outflowWindow holds the recent outflows of one holder and token and
+// whether their sum is over the limit, so the window alarms once per crossing.
+// Through is the last block evaluated, so a range handed over again neither
+// alarms nor counts twice.
+type outflowWindow struct {
+	Outflows []outflow
+	Over     bool
+	Through  uint64
+}
+
+// HandleRange checks the outflows of the contractToken holders in blocks
+// [from, to], handed over by the chain's block scanner. It alarms on every
+// single outflow above the limit of its token and when the outflows of a
+// rolling window reach Outflow.Percent of the holder's balance. An error
+// reading the transfers leaves the range to be handed over again, so none is
+// missed; a failed balance read only skips the window check of the range.
+// While the scanner catches up, after a restart or a stall, ranges more than
+// a few batches behind head get no window check, since the balance at head
+// says nothing about the holder back then; the windows are kept in memory
+// only and start empty after a restart.
+func (m *Monitor) HandleRange(ctx context.Context, from, to uint64) error {
+	snap := m.Snapshot()
+	limits := m.outflowLimits(snap)
+	if len(limits) == 0 {
+		return nil
+	}
+	rng, err := readOutflows(ctx, m.Conn.Client(), limits, from, to)
+	if err != nil {
+		return err
+	}
+	if rng.Behind {
+		m.Log.Info("Outflow window not checked while catching up", "from", from, "to", to)
+	}
+	for key, limit := range limits {
+		if _, err := rng.Balances.get(key); limit.Window > 0 && err != nil && !rng.Behind {
+			m.Log.Warn("Outflow balance read failed, window not checked", "holder", key.Holder, "token", limit.Name, "err", err)
+		}
+	}
+	m.Log.Info("Outflow check", "from", from, "to", to, "outflows", len(rng.Outflows))
+	for _, alarm := range evaluateOutflows(m.outflows, rng, limits, snap.Name) {
+		util.Alarm(ctx, alarm)
+	}
+	return nil
+}
+
+// outflowLimits returns the thresholds of every holder and token snap sets
+// an outflow alarm for.
+func (m *Monitor) outflowLimits(snap config.OptConfig) map[balanceKey]outflowLimit {
+	limits := make(map[balanceKey]outflowLimit)
+	for _, ct := range snap.ContractToken {
+		holder := common.HexToAddress(ct.Address)
+		var (
+			window  time.Duration
+			percent float64
+		)
+		if ct.Outflow != nil {
+			window, percent = time.Duration(ct.Outflow.Window)*time.Second, ct.Outflow.Percent
+			native := outflowLimit{Name: snap.Symbol, Decimals: nativeDecimals, Window: window, Percent: percent}
+			if native.Name == "" {
+				native.Name = "native"
+			}
+			native.Traces = ct.Outflow.Traces
+			if ct.Outflow.MaxNative != "" {
+				max, err := amount.Parse(ct.Outflow.MaxNative, nativeDecimals)
+				if err != nil {
+					m.Log.Error("Outflow maxNative invalid", "holder", holder, "maxNative", ct.Outflow.MaxNative, "err", err)
+				} else {
+					native.Max = max.Raw()
+				}
+			}
+			if native.Max != nil || native.Window > 0 {
+				limits[balanceKey{Holder: holder}] = native
+			}
+		}
+		for _, tk := range ct.Tokens {
+			if tk.MaxTransfer <= 0 && window <= 0 {
+				continue
+			}
+			meta := m.tokens.Resolve(tk)
+			limit := outflowLimit{Name: tk.Name, Decimals: meta.Decimals, Window: window, Percent: percent}
+			if tk.MaxTransfer > 0 {
+				max, err := amount.FromFloat(tk.MaxTransfer, meta.Decimals)
+				if err != nil {
+					m.Log.Error("Token maxTransfer invalid", "token", tk.Name, "err", err)
+				} else {
+					limit.Max = max.Raw()
+				}
+			}
+			limits[balanceKey{Token: common.HexToAddress(tk.Addr), Holder: holder}] = limit
+		}
+	}
+	return limits
+}
+
+// readOutflows reads what left the holders of limits in blocks [from, to]:
+// their ERC-20 Transfer events and their native transfers, the latter from
+// the sent transactions or, with Traces, from trace_filter so that calls
+// made by contracts count too. Only headers, logs and transactions of the
+// range are read, which a pruned node still serves for old blocks; the
+// balances the window compares against are read at head, not at all when to
+// is more than outflowCatchUpBatches batches behind it, and a failed read
+// only leaves its key out of rng.Balances.
+func readOutflows(ctx context.Context, client *ethclient.Client, limits map[balanceKey]outflowLimit, from, to uint64) (outflowRange, error) {
+	rng := outflowRange{From: from, To: to}
+	header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+	if err != nil {
+		return rng, errors.Wrapf(err, "header %d", to)
+	}
+	rng.Time = int64(header.Time)
+
+	var (
+		keys       []balanceKey
+		tokens     []common.Address
+		holders    []common.Hash
+		senders    = make(map[common.Address]bool)
+		tracers    = make(map[common.Address]bool)
+		seenToken  = make(map[common.Address]bool)
+		seenHolder = make(map[common.Address]bool)
+	)
+	for key, limit := range limits {
+		if limit.Window > 0 {
+			keys = append(keys, key)
+		}
+		if key.Token == (common.Address{}) {
+			if limit.Traces {
+				tracers[key.Holder] = true
+			} else {
+				senders[key.Holder] = true
+			}
+			continue
+		}
+		if !seenToken[key.Token] {
+			seenToken[key.Token] = true
+			tokens = append(tokens, key.Token)
+		}
+		if !seenHolder[key.Holder] {
+			seenHolder[key.Holder] = true
+			holders = append(holders, common.BytesToHash(key.Holder.Bytes()))
+		}
+	}
+
+	if len(tokens) > 0 {
+		logs, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: tokens,
+			Topics:    [][]common.Hash{{transferTopic}, holders},
+		})
+		if err != nil {
+			return rng, errors.Wrapf(err, "transfer logs %d-%d", from, to)
+		}
+		for _, l := range logs {
+			// ERC-721 Transfer carries the token id as a third indexed topic
+			if l.Removed || len(l.Topics) != 3 || len(l.Data) != 32 {
+				continue
+			}
+			key := balanceKey{Token: l.Address, Holder: common.BytesToAddress(l.Topics[1].Bytes())}
+			if _, ok := limits[key]; !ok {
+				continue
+			}
+			ts := rng.Time
+			if l.BlockTimestamp != 0 {
+				ts = int64(l.BlockTimestamp)
+			}
+			rng.Outflows = append(rng.Outflows, outflow{Key: key, Amount: new(big.Int).SetBytes(l.Data),
+				Tx: l.TxHash, Block: l.BlockNumber, Time: ts})
+		}
+	}
+
+	var sent []outflow
+	if len(senders) > 0 {
+		if sent, err = readNativeTxs(ctx, client.Client(), senders, from, to); err != nil {
+			return rng, err
+		}
+	}
+	if len(tracers) > 0 {
+		traced, err := readNativeTraces(ctx, client.Client(), tracers, from, to)
+		if err != nil {
+			return rng, err
+		}
+		sent = append(sent, traced...)
+	}
+	for _, o := range sent {
+		if o.Time == 0 {
+			o.Time = rng.Time
+		}
+		rng.Outflows = append(rng.Outflows, o)
+	}
+	sort.SliceStable(rng.Outflows, func(i, j int) bool { return rng.Outflows[i].Block < rng.Outflows[j].Block })
+
+	if len(keys) == 0 {
+		return rng, nil
+	}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		// like a failed balance read, only the window check is skipped
+		return rng, nil
+	}
+	if lag := outflowCatchUpBatches * max(to-from+1, config.DefaultScanBatch); head > to+lag {
+		rng.Behind = true
+		return rng, nil
+	}
+	// per-key errors stay in the set, evaluateOutflows skips those keys
+	rng.Balances, _ = rpcBatchBalances(ctx, client.Client(), keys)
+	return rng, nil
+}
+
+// rpcTx is the part of a transaction in eth_getBlockByNumber readNativeTxs
+// needs.
+type rpcTx struct {
+	Hash  common.Hash    `json:"hash"`
+	From  common.Address `json:"from"`
+	Value *hexutil.Big   `json:"value"`
+}
+
+type rpcBlock struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	Transactions []rpcTx        `json:"transactions"`
+}
+
+type rpcReceipt struct {
+	Status hexutil.Uint64 `json:"status"`
+}
+
+// readNativeTxs returns the value of every successful transaction that
+// holders sent in blocks [from, to].
+func readNativeTxs(ctx context.Context, client *rpc.Client, holders map[common.Address]bool, from, to uint64) ([]outflow, error) {
+	var sent []outflow
+	for start := from; start <= to; start += balanceBatchSize {
+		end := min(start+balanceBatchSize-1, to)
+		elems := make([]rpc.BatchElem, 0, end-start+1)
+		for n := start; n <= end; n++ {
+			elems = append(elems, rpc.BatchElem{Method: "eth_getBlockByNumber",
+				Args: []interface{}{hexutil.EncodeUint64(n), true}, Result: new(rpcBlock)})
+		}
+		if err := client.BatchCallContext(ctx, elems); err != nil {
+			return nil, errors.Wrapf(err, "blocks %d-%d", start, end)
+		}
+		for _, elem := range elems {
+			if elem.Error != nil {
+				return nil, errors.Wrapf(elem.Error, "block %v", elem.Args[0])
+			}
+			block := elem.Result.(*rpcBlock)
+			for _, tx := range block.Transactions {
+				if !holders[tx.From] || tx.Value == nil || tx.Value.ToInt().Sign() <= 0 {
+					continue
+				}
+				sent = append(sent, outflow{Key: balanceKey{Holder: tx.From}, Amount: tx.Value.ToInt(),
+					Tx: tx.Hash, Block: uint64(block.Number), Time: int64(block.Timestamp)})
+			}
+		}
+	}
+	if len(sent) == 0 {
+		return nil, nil
+	}
+
+	// a reverted transaction moved nothing
+	elems := make([]rpc.BatchElem, len(sent))
+	for i, o := range sent {
+		elems[i] = rpc.BatchElem{Method: "eth_getTransactionReceipt", Args: []interface{}{o.Tx}, Result: new(rpcReceipt)}
+	}
+	if err := client.BatchCallContext(ctx, elems); err != nil {
+		return nil, errors.Wrap(err, "receipts")
+	}
+	kept := sent[:0]
+	for i, o := range sent {
+		if elems[i].Error != nil {
+			return nil, errors.Wrapf(elems[i].Error, "receipt %s", o.Tx.Hex())
+		}
+		if uint64(elems[i].Result.(*rpcReceipt).Status) == types.ReceiptStatusSuccessful {
+			kept = append(kept, o)
+		}
+	}
+	return kept, nil
+}
+
+// rpcTrace is the part of a trace_filter entry readNativeTraces needs.
+type rpcTrace struct {
+	Action struct {
+		From     common.Address `json:"from"`
+		Value    *hexutil.Big   `json:"value"`
+		CallType string         `json:"callType"`
+	} `json:"action"`
+	BlockNumber     uint64      `json:"blockNumber"`
+	TransactionHash common.Hash `json:"transactionHash"`
+	Type            string      `json:"type"`
+	Error           string      `json:"error"`
+}
+
+// readNativeTraces returns every native value holders sent in blocks
+// [from, to] by a call or a contract creation, including the internal ones
+// of contract calls. It needs a node serving trace_filter.
+func readNativeTraces(ctx context.Context, client *rpc.Client, holders map[common.Address]bool, from, to uint64) ([]outflow, error) {
+	addrs := make([]common.Address, 0, len(holders))
+	for holder := range holders {
+		addrs = append(addrs, holder)
+	}
+	var traces []rpcTrace
+	err := client.CallContext(ctx, &traces, "trace_filter", map[string]interface{}{
+		"fromBlock":   hexutil.EncodeUint64(from),
+		"toBlock":     hexutil.EncodeUint64(to),
+		"fromAddress": addrs,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "trace_filter %d-%d", from, to)
+	}
+	var sent []outflow
+	for _, tr := range traces {
+		// delegatecall and staticcall move no value of their own
+		if tr.Error != "" || (tr.Type != "call" && tr.Type != "create") || (tr.Type == "call" && tr.Action.CallType != "call") {
+			continue
+		}
+		if !holders[tr.Action.From] || tr.Action.Value == nil || tr.Action.Value.ToInt().Sign() <= 0 {
+			continue
+		}
+		sent = append(sent, outflow{Key: balanceKey{Holder: tr.Action.From}, Amount: tr.Action.Value.ToInt(),
+			Tx: tr.TransactionHash, Block: tr.BlockNumber})
+	}
+	return sent, nil
+}
+
+// evaluateOutflows returns the alarms of rng: one per outflow above the
+// limit of its token, and one per holder and token whose outflows within
+// the window reach Percent of its balance before them. windows carries the
+// rolling windows between ranges; outflows in blocks an earlier range
+// already evaluated are skipped.
+func evaluateOutflows(windows map[balanceKey]*outflowWindow, rng outflowRange, limits map[balanceKey]outflowLimit, chain string) []string {
+	var alarms []string
+	for key := range limits {
+		if windows[key] == nil {
+			windows[key] = &outflowWindow{}
+		}
+	}
+	for _, o := range rng.Outflows {
+		limit, w := limits[o.Key], windows[o.Key]
+		if w == nil || o.Block <= w.Through {
+			continue
+		}
+		if limit.Max != nil && o.Amount.Cmp(limit.Max) > 0 {
+			alarms = append(alarms, fmt.Sprintf("Large outflow,chains=%s holder=%s token=%s amount=%s max=%s tx=%s block=%d",
+				chain, o.Key.Holder, limit.Name, amount.New(o.Amount, limit.Decimals), amount.New(limit.Max, limit.Decimals),
+				o.Tx.Hex(), o.Block))
+		}
+		if limit.Window > 0 {
+			w.Outflows = append(w.Outflows, o)
+		}
+	}
+
+	keys := make([]balanceKey, 0, len(limits))
+	for key, limit := range limits {
+		windows[key].Through = max(windows[key].Through, rng.To)
+		if limit.Window > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Holder != keys[j].Holder {
+			return keys[i].Holder.Hex() < keys[j].Holder.Hex()
+		}
+		return keys[i].Token.Hex() < keys[j].Token.Hex()
+	})
+	for _, key := range keys {
+		limit, w := limits[key], windows[key]
+		since := rng.Time - int64(limit.Window.Seconds())
+		kept := w.Outflows[:0]
+		sum := new(big.Int)
+		for _, o := range w.Outflows {
+			if o.Time > since {
+				kept = append(kept, o)
+				sum.Add(sum, o.Amount)
+			}
+		}
+		w.Outflows = kept
+		balance, err := rng.Balances.get(key)
+		if err != nil {
+			continue
+		}
+		over := outflowOver(sum, balance, limit.Percent)
+		if over && !w.Over {
+			alarms = append(alarms, fmt.Sprintf("Outflow over %g%% of balance within %s,chains=%s holder=%s token=%s outflow=%s balance=%s",
+				limit.Percent, limit.Window, chain, key.Holder, limit.Name, amount.New(sum, limit.Decimals),
+				amount.New(balance, limit.Decimals)))
+		}
+		w.Over = over
+	}
+	return alarms
+}
+
+// outflowOver reports whether sum is at least percent of what the holder
+// held before it left: balance + sum.
+func outflowOver(sum, balance *big.Int, percent float64) bool {
+	if sum.Sign() <= 0 {
+		return false
+	}
+	held := new(big.Int).Add(balance, sum)
+	// compare in basis points: sum * 10000 >= percent * 100 * held
+	lhs := new(big.Int).Mul(sum, big.NewInt(10000))
+	rhs := new(big.Int).Mul(held, big.NewInt(int64(percent*100)))
+	return lhs.Cmp(rhs) >= 0
+}
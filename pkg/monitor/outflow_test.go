@@ -0,0 +1,217 @@
+package monitor
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mapprotocol/monitor/internal/config"
+)
+
+func TestEvaluateOutflows(t *testing.T) {
+	tokenKey := balanceKey{Token: testToken, Holder: testHolder1}
+	nativeKey := balanceKey{Holder: testHolder1}
+	limits := map[balanceKey]outflowLimit{
+		tokenKey:  {Name: "usdt", Decimals: 6, Max: big.NewInt(1000e6), Window: time.Hour, Percent: 20},
+		nativeKey: {Name: "ETH", Decimals: 18, Max: big.NewInt(5e18)},
+	}
+	windows := make(map[balanceKey]*outflowWindow)
+	balances := func(token int64) balanceSet {
+		return balanceSet{tokenKey: {Value: big.NewInt(token)}, nativeKey: {Value: big.NewInt(1e18)}}
+	}
+
+	small := []outflow{{Key: tokenKey, Amount: big.NewInt(600e6), Block: 15, Time: 2000}}
+	steps := []struct {
+		to       uint64
+		time     int64
+		outflows []outflow
+		balance  int64
+		alarms   []string
+	}{
+		{ // a large transfer and a native drop, 1500 of 10000 held: below 20%
+			to:   12,
+			time: 1000,
+			outflows: []outflow{
+				{Key: tokenKey, Amount: big.NewInt(1500e6), Tx: common.HexToHash("0x01"), Block: 10, Time: 1000},
+				{Key: nativeKey, Amount: big.NewInt(6e18), Block: 12, Time: 1000},
+			},
+			balance: 8500e6,
+			alarms:  []string{"Large outflow,chains=t holder=" + testHolder1.Hex() + " token=usdt amount=1500", "token=ETH amount=6"},
+		},
+		{ // small transfers add up to 2100 of 10000 within the hour
+			to:       20,
+			time:     2000,
+			outflows: small,
+			balance:  7900e6,
+			alarms:   []string{"Outflow over 20% of balance within 1h0m0s"},
+		},
+		{ // the range handed over again neither alarms nor counts twice
+			to:       20,
+			time:     2000,
+			outflows: small,
+			balance:  7900e6,
+		},
+		{ // still over, alarmed once
+			to:       30,
+			time:     2100,
+			outflows: []outflow{{Key: tokenKey, Amount: big.NewInt(100e6), Block: 25, Time: 2100}},
+			balance:  7800e6,
+		},
+		{ // the first transfer left the window
+			to:      40,
+			time:    4700,
+			balance: 7800e6,
+		},
+	}
+	for i, step := range steps {
+		rng := outflowRange{From: step.to - 9, To: step.to, Time: step.time, Outflows: step.outflows, Balances: balances(step.balance)}
+		alarms := evaluateOutflows(windows, rng, limits, "t")
+		if len(alarms) != len(step.alarms) {
+			t.Fatalf("step %d: alarms %v, want %v", i, alarms, step.alarms)
+		}
+		for j, want := range step.alarms {
+			if !strings.Contains(alarms[j], want) {
+				t.Fatalf("step %d: alarm %q, want %q", i, alarms[j], want)
+			}
+		}
+	}
+	if w := windows[tokenKey]; w.Over || len(w.Outflows) != 2 {
+		t.Fatalf("window = %+v", w)
+	}
+}
+
+func TestOutflowOver(t *testing.T) {
+	if !outflowOver(big.NewInt(25), big.NewInt(75), 25) {
+		t.Fatal("25 of 100 held is 25%")
+	}
+	if outflowOver(big.NewInt(24), big.NewInt(76), 25) {
+		t.Fatal("24 of 100 held is below 25%")
+	}
+	if outflowOver(new(big.Int), new(big.Int), 0) {
+		t.Fatal("no outflow is never over")
+	}
+}
+
+func TestReadOutflows(t *testing.T) {
+	from := common.BytesToHash(testHolder1.Bytes())
+	other := common.BytesToHash(testHolder2.Bytes())
+	amount := common.LeftPadBytes(big.NewInt(42).Bytes(), 32)
+	f := &fakeChain{
+		pruned: true, // the range is older than the state the node keeps
+		native: map[common.Address]int64{testHolder1: 7},
+		tokens: map[common.Address]int64{testHolder1: 100},
+		logs: []types.Log{
+			{Address: testToken, Topics: []common.Hash{transferTopic, from, other}, Data: amount, BlockNumber: 11, TxHash: common.HexToHash("0x01")},
+			{Address: testToken, Topics: []common.Hash{transferTopic, other, from}, Data: amount, BlockNumber: 11}, // holder not watched
+			{Address: testToken, Topics: []common.Hash{transferTopic, from, other, {}}, BlockNumber: 12},           // ERC-721
+		},
+		blockTxs: map[uint64][]rpcTx{
+			10: {{Hash: common.HexToHash("0x02"), From: testHolder1, Value: (*hexutil.Big)(big.NewInt(3))}},
+			12: {
+				{Hash: common.HexToHash("0x03"), From: testHolder1, Value: (*hexutil.Big)(big.NewInt(5))}, // reverted
+				{Hash: common.HexToHash("0x04"), From: testHolder2, Value: (*hexutil.Big)(big.NewInt(9))}, // holder not watched
+				{Hash: common.HexToHash("0x05"), From: testHolder1, Value: (*hexutil.Big)(new(big.Int))},
+			},
+		},
+		reverted: map[common.Hash]bool{common.HexToHash("0x03"): true},
+	}
+	m := newFakeMonitor(t, f)
+	limits := map[balanceKey]outflowLimit{
+		{Token: testToken, Holder: testHolder1}:    {Name: "usdt", Max: big.NewInt(10), Window: time.Hour, Percent: 10},
+		{Token: testBadToken, Holder: testHolder1}: {Name: "bad", Window: time.Hour, Percent: 10},
+		{Holder: testHolder1}:                      {Name: "ETH", Max: big.NewInt(1)},
+	}
+
+	rng, err := readOutflows(context.Background(), m.Conn.Client(), limits, 10, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rng.Outflows) != 2 {
+		t.Fatalf("outflows = %+v", rng.Outflows)
+	}
+	if o := rng.Outflows[0]; o.Key.Token != (common.Address{}) || o.Amount.Int64() != 3 || o.Block != 10 || o.Time != 100 {
+		t.Fatalf("native outflow = %+v", o)
+	}
+	if o := rng.Outflows[1]; o.Key.Token != testToken || o.Amount.Int64() != 42 || o.Block != 11 {
+		t.Fatalf("token outflow = %+v", o)
+	}
+	if balance, _ := rng.Balances.get(balanceKey{Token: testToken, Holder: testHolder1}); balance == nil || balance.Int64() != 100 {
+		t.Fatalf("token balance = %v", balance)
+	}
+	// a failed balance read leaves the range to the window check of the others
+	if _, err := rng.Balances.get(balanceKey{Token: testBadToken, Holder: testHolder1}); err == nil {
+		t.Fatal("expected the reverting token to fail")
+	}
+
+	// the checkpoint must not wait for a balance the node cannot serve
+	m.UpdateCfg(func(cfg *config.OptConfig) {
+		cfg.ContractToken = []config.ContractToken{{
+			Address: testHolder1.Hex(),
+			Tokens:  []config.EthToken{{Name: "bad", Addr: testBadToken.Hex()}},
+			Outflow: &config.Outflow{MaxNative: "1", Window: 3600, Percent: 10},
+		}}
+	})
+	if err := m.HandleRange(context.Background(), 10, 12); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadOutflows_CatchingUp(t *testing.T) {
+	f := &fakeChain{head: 12 + outflowCatchUpBatches*config.DefaultScanBatch + 1}
+	m := newFakeMonitor(t, f)
+	limits := map[balanceKey]outflowLimit{{Holder: testHolder1}: {Name: "ETH", Window: time.Hour, Percent: 10}}
+
+	rng, err := readOutflows(context.Background(), m.Conn.Client(), limits, 10, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rng.Behind || rng.Balances != nil {
+		t.Fatalf("range far behind head read balances %v, behind=%v", rng.Balances, rng.Behind)
+	}
+
+	f.head--
+	rng, err = readOutflows(context.Background(), m.Conn.Client(), limits, 10, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rng.Behind {
+		t.Fatal("range within the catch-up lag skipped its window check")
+	}
+	if _, err := rng.Balances.get(balanceKey{Holder: testHolder1}); err != nil {
+		t.Fatalf("native balance not read: %v", err)
+	}
+}
+
+func TestReadOutflows_Traces(t *testing.T) {
+	trace := func(typ, callType string, from common.Address, value int64, failed string) rpcTrace {
+		var tr rpcTrace
+		tr.Type, tr.Action.CallType, tr.Action.From, tr.Error = typ, callType, from, failed
+		tr.Action.Value = (*hexutil.Big)(big.NewInt(value))
+		tr.BlockNumber, tr.TransactionHash = 11, common.HexToHash("0x01")
+		return tr
+	}
+	f := &fakeChain{traces: []rpcTrace{
+		trace("call", "call", testHolder1, 4, ""),
+		trace("call", "delegatecall", testHolder1, 4, ""),
+		trace("call", "call", testHolder1, 4, "Reverted"),
+		trace("create", "", testHolder1, 2, ""),
+	}}
+	m := newFakeMonitor(t, f)
+	limits := map[balanceKey]outflowLimit{{Holder: testHolder1}: {Name: "ETH", Max: big.NewInt(1), Traces: true}}
+
+	rng, err := readOutflows(context.Background(), m.Conn.Client(), limits, 10, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rng.Outflows) != 2 || rng.Outflows[0].Amount.Int64() != 4 || rng.Outflows[1].Amount.Int64() != 2 {
+		t.Fatalf("outflows = %+v", rng.Outflows)
+	}
+	if rng.Outflows[0].Time != rng.Time {
+		t.Fatalf("traced outflow at %d, want the range time %d", rng.Outflows[0].Time, rng.Time)
+	}
+}
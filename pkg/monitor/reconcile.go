@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/internal/mapprotocol"
+	"github.com/mapprotocol/monitor/pkg/util"
+	"github.com/shopspring/decimal"
+)
+
+// termFetcher returns the raw value of a term, in term.Decimals. It is a
+// decimal because BRC-20 balances come in token units with a fraction.
+type termFetcher func(term config.ReconcileTerm) (decimal.Decimal, error)
+
+type reconcileResult struct {
+	Backing   decimal.Decimal
+	Supply    decimal.Decimal
+	Shortfall decimal.Decimal
+	Tolerance decimal.Decimal
+}
+
+// Insolvent reports whether backing falls below supply by more than the
+// rule's tolerance.
+func (r *reconcileResult) Insolvent() bool {
+	return r.Shortfall.GreaterThan(r.Tolerance)
+}
+
+// reconcileCheck evaluates every solvency rule and alarms on those whose
+// backing falls short of supply.
+func (m *Monitor) reconcileCheck() {
+	rules := m.Cfg.Tk.Reconciles
+	if len(rules) == 0 {
+		rules = legacyReconciles(m.Cfg.Tk)
+	}
+	for _, rule := range rules {
+		result, err := evaluateReconcile(rule, m.fetchTerm)
+		if err != nil {
+			m.Log.Error("Check bridge solvency failed", "rule", rule.Name, "err", err)
+			continue
+		}
+		m.Log.Info("Check bridge solvency", "rule", rule.Name, "backing", result.Backing,
+			"supply", result.Supply, "shortfall", result.Shortfall)
+		if result.Insolvent() {
+			util.Alarm(context.Background(), fmt.Sprintf("check bridge solvency rule=%s, backing=%s, supply=%s, shortfall=%s, tolerance=%s",
+				rule.Name, result.Backing, result.Supply, result.Shortfall, result.Tolerance))
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func evaluateReconcile(rule config.Reconcile, fetch termFetcher) (*reconcileResult, error) {
+	tolerance := decimal.Zero
+	if rule.Tolerance != "" {
+		var err error
+		tolerance, err = decimal.NewFromString(rule.Tolerance)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse tolerance %q", rule.Tolerance)
+		}
+	}
+	backing, err := sumTerms(rule.Sources, fetch)
+	if err != nil {
+		return nil, errors.Wrap(err, "sources")
+	}
+	supply, err := sumTerms(rule.Sinks, fetch)
+	if err != nil {
+		return nil, errors.Wrap(err, "sinks")
+	}
+	return &reconcileResult{
+		Backing:   backing,
+		Supply:    supply,
+		Shortfall: supply.Sub(backing),
+		Tolerance: tolerance,
+	}, nil
+}
+
+func sumTerms(terms []config.ReconcileTerm, fetch termFetcher) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, term := range terms {
+		var value decimal.Decimal
+		if term.Kind == config.TermManual {
+			var err error
+			value, err = decimal.NewFromString(term.Amount)
+			if err != nil {
+				return decimal.Zero, errors.Wrapf(err, "manual amount %q", term.Amount)
+			}
+		} else {
+			raw, err := fetch(term)
+			if err != nil {
+				return decimal.Zero, errors.Wrapf(err, "%s %s", term.Kind, term.Token)
+			}
+			value = raw.Shift(-term.Decimals)
+		}
+		if term.Subtract {
+			value = value.Neg()
+		}
+		total = total.Add(value)
+	}
+	return total, nil
+}
+
+// legacyReconciles derives the rules mapCheck used to hard-code: for "btc"
+// the BTC bridge balance must cover the MAP minter total, for any other tick
+// the BRC-20 bridge balance (plus its offset) must cover the MAP
+// totalSupply minus what is locked in MapBridge.
+func legacyReconciles(tk *config.Token) []config.Reconcile {
+	rules := make([]config.Reconcile, 0, len(tk.Contracts))
+	for idx, contract := range tk.Contracts {
+		if idx >= len(tk.Token) {
+			break
+		}
+		tick := tk.Token[idx]
+		if tick == "btc" {
+			rules = append(rules, config.Reconcile{
+				Name: tick,
+				Sources: []config.ReconcileTerm{
+					{Kind: config.TermBtcBalance, Holder: strings.Split(tk.BtcBridgeAddr, ",")[0], Decimals: 8},
+				},
+				Sinks: []config.ReconcileTerm{
+					{Kind: config.TermMinterTotal, Token: contract, Holder: tk.MapBridge, Decimals: 18},
+				},
+			})
+			continue
+		}
+
+		sources := []config.ReconcileTerm{{Kind: config.TermBrc20Balance, Token: tick, Holder: tk.BridgeAddr}}
+		if offset := tk.Offsets[tick]; offset != "" {
+			sources = append(sources, config.ReconcileTerm{Kind: config.TermManual, Amount: offset})
+		}
+		rules = append(rules, config.Reconcile{
+			Name:    tick,
+			Sources: sources,
+			Sinks: []config.ReconcileTerm{
+				{Kind: config.TermEvmTotalSupply, Token: contract, Decimals: 18},
+				{Kind: config.TermEvmBalance, Token: contract, Holder: tk.MapBridge, Decimals: 18, Subtract: true},
+			},
+		})
+	}
+	return rules
+}
+
+// fetchTerm reads the raw value of term, summed over its holders.
+func (m *Monitor) fetchTerm(term config.ReconcileTerm) (decimal.Decimal, error) {
+	switch term.Kind {
+	case config.TermEvmTotalSupply:
+		supply, err := mapprotocol.TotalSupply(term.Token)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return decimal.NewFromBigInt(supply, 0), nil
+	case config.TermBrc20Balance:
+		holder := term.Holder
+		if holder == "" {
+			holder = m.Cfg.Tk.BridgeAddr
+		}
+		return m.brc20Balance(holder, term.Token)
+	}
+
+	total := big.NewInt(0)
+	for _, holder := range strings.Split(term.Holder, ",") {
+		holder = strings.TrimSpace(holder)
+		if holder == "" {
+			continue
+		}
+		value, err := m.fetchHolderTerm(term, holder)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total.Add(total, value)
+	}
+	return decimal.NewFromBigInt(total, 0), nil
+}
+
+func (m *Monitor) fetchHolderTerm(term config.ReconcileTerm, holder string) (*big.Int, error) {
+	switch term.Kind {
+	case config.TermEvmBalance:
+		return mapprotocol.BalanceOf(term.Token, common.HexToAddress(holder))
+	case config.TermEvmNative:
+		return mapprotocol.GlobalMapConn.BalanceAt(context.Background(), common.HexToAddress(holder), nil)
+	case config.TermMinterTotal:
+		ret := mapprotocol.MinterCapResp{}
+		if err := mapprotocol.Call(term.Token, mapprotocol.MinterCapMethod, common.HexToAddress(holder), &ret); err != nil {
+			return nil, err
+		}
+		return ret.Total, nil
+	case config.TermBtcBalance:
+		balances, err := getBtcBalanceByMem(m.Cfg.Tk.Esplora, holder)
+		if err != nil {
+			return nil, err
+		}
+		return big.NewInt(balances.Confirmed), nil
+	default:
+		return nil, errors.Errorf("unknown reconcile term kind %q", term.Kind)
+	}
+}
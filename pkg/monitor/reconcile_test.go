@@ -0,0 +1,147 @@
+package monitor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+func fakeFetcher(values map[string]string) termFetcher {
+	return func(term config.ReconcileTerm) (decimal.Decimal, error) {
+		value, ok := values[term.Kind+":"+term.Token]
+		if !ok {
+			return decimal.Zero, errors.New("no value")
+		}
+		return decimal.RequireFromString(value), nil
+	}
+}
+
+func TestEvaluateReconcile_ExactDecimals(t *testing.T) {
+	rule := config.Reconcile{
+		Name: "ordi",
+		Sources: []config.ReconcileTerm{
+			{Kind: config.TermBrc20Balance, Token: "ordi"},
+			{Kind: config.TermManual, Amount: "0.5"},
+		},
+		Sinks: []config.ReconcileTerm{
+			{Kind: config.TermEvmTotalSupply, Token: "0xa", Decimals: 18},
+			{Kind: config.TermEvmBalance, Token: "0xa", Decimals: 18, Subtract: true},
+		},
+	}
+	fetch := fakeFetcher(map[string]string{
+		config.TermBrc20Balance + ":ordi":  "1000",
+		config.TermEvmTotalSupply + ":0xa": "1200000000000000000001", // 1200.000000000000000001
+		config.TermEvmBalance + ":0xa":     "199500000000000000000",  // 199.5
+	})
+
+	got, err := evaluateReconcile(rule, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Backing.Equal(decimal.RequireFromString("1000.5")) {
+		t.Fatalf("backing = %s, want 1000.5", got.Backing)
+	}
+	if !got.Supply.Equal(decimal.RequireFromString("1000.500000000000000001")) {
+		t.Fatalf("supply = %s, want 1000.500000000000000001", got.Supply)
+	}
+	if !got.Insolvent() {
+		t.Fatal("a shortfall of 1 wei must be reported with zero tolerance")
+	}
+
+	rule.Tolerance = "0.000001"
+	got, err = evaluateReconcile(rule, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Insolvent() {
+		t.Fatalf("shortfall %s within tolerance %s reported", got.Shortfall, got.Tolerance)
+	}
+}
+
+func TestEvaluateReconcile_FractionalBrc20(t *testing.T) {
+	// the derived legacy rule of a tick: exact on both sides, zero tolerance
+	rule := legacyReconciles(&config.Token{BridgeAddr: "bc1pa", MapBridge: "0xbridge",
+		Token: []string{"ordi"}, Contracts: []string{"0xa"}})[0]
+	fetch := fakeFetcher(map[string]string{
+		config.TermBrc20Balance + ":ordi":  "1000.25",
+		config.TermEvmTotalSupply + ":0xa": "1200250000000000000000", // 1200.25
+		config.TermEvmBalance + ":0xa":     "200000000000000000000",  // 200
+	})
+	got, err := evaluateReconcile(rule, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Insolvent() || !got.Shortfall.IsZero() {
+		t.Fatalf("fractional balance covering supply exactly reported a shortfall of %s", got.Shortfall)
+	}
+}
+
+func TestEvaluateReconcile_FetchError(t *testing.T) {
+	rule := config.Reconcile{
+		Name:    "btc",
+		Sources: []config.ReconcileTerm{{Kind: config.TermBtcBalance, Holder: "bc1q"}},
+		Sinks:   []config.ReconcileTerm{{Kind: config.TermManual, Amount: "1"}},
+	}
+	if _, err := evaluateReconcile(rule, fakeFetcher(nil)); err == nil {
+		t.Fatal("expected error when a source cannot be fetched")
+	}
+}
+
+func TestLegacyReconciles(t *testing.T) {
+	tk := &config.Token{
+		BridgeAddr:    "bc1pa,bc1pb",
+		BtcBridgeAddr: "bc1qa,bc1qb",
+		MapBridge:     "0xbridge",
+		Token:         []string{"btc", "roup", "ordi"},
+		Contracts:     []string{"0xbtc", "0xroup", "0xordi"},
+	}
+	rules := legacyReconciles(tk)
+	if len(rules) != 3 {
+		t.Fatalf("got %d rules, want 3", len(rules))
+	}
+
+	btc := rules[0]
+	if btc.Sources[0].Kind != config.TermBtcBalance || btc.Sources[0].Holder != "bc1qa" || btc.Sources[0].Decimals != 8 {
+		t.Fatalf("btc source = %+v", btc.Sources[0])
+	}
+	if btc.Sinks[0].Kind != config.TermMinterTotal || btc.Sinks[0].Holder != "0xbridge" || btc.Sinks[0].Decimals != 18 {
+		t.Fatalf("btc sink = %+v", btc.Sinks[0])
+	}
+
+	if len(rules[1].Sources) != 1 || len(rules[2].Sources) != 1 {
+		t.Fatalf("no offset applies without token.offsets, got roup=%+v ordi=%+v", rules[1].Sources, rules[2].Sources)
+	}
+	if !rules[2].Sinks[1].Subtract || rules[2].Sinks[1].Holder != "0xbridge" {
+		t.Fatalf("ordi lock sink = %+v", rules[2].Sinks[1])
+	}
+
+	tk.Offsets = map[string]string{"roup": "900000"}
+	rules = legacyReconciles(tk)
+	roup := rules[1]
+	if len(roup.Sources) != 2 || roup.Sources[1].Kind != config.TermManual || roup.Sources[1].Amount != "900000" {
+		t.Fatalf("roup offset not applied, sources = %+v", roup.Sources)
+	}
+	if len(rules[2].Sources) != 1 {
+		t.Fatalf("ordi has no offset, sources = %+v", rules[2].Sources)
+	}
+}
+
+// TestLegacyReconciles_BtcUnits: the derived btc rule compares satoshis with
+// the 18-decimal MAP minter total, as the old total/1e10 check did.
+func TestLegacyReconciles_BtcUnits(t *testing.T) {
+	tk := &config.Token{MapBridge: "0xbridge", Token: []string{"btc"}, Contracts: []string{"0xbtc"}, BtcBridgeAddr: "bc1q"}
+	rule := legacyReconciles(tk)[0]
+	fetch := fakeFetcher(map[string]string{
+		config.TermBtcBalance + ":":       "150000000",           // 1.5 BTC
+		config.TermMinterTotal + ":0xbtc": "1500000000000000000", // 1.5 BTC on MAP
+	})
+	got, err := evaluateReconcile(rule, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Insolvent() {
+		t.Fatalf("fully backed btc reported insolvent, shortfall %s", got.Shortfall)
+	}
+}
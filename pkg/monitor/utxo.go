@@ -0,0 +1,165 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/cockroachdb/errors"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/mapprotocol/monitor/pkg/mempool"
+	"github.com/mapprotocol/monitor/pkg/util"
+)
+
+// defaultDustValue is 0.0001 BTC in satoshis.
+const defaultDustValue = 10000
+
+// btcAmount converts satoshis for logging and alarms.
+func btcAmount(sats int64) amount.Amount {
+	return amount.FromInt64(sats, 8)
+}
+
+type utxoSummary struct {
+	Count       int
+	Dust        int
+	Unconfirmed int
+	Largest     int64
+	Smallest    int64
+	Total       int64
+}
+
+// utxoThresholds is config.UtxoHealth with amounts converted to satoshis.
+type utxoThresholds struct {
+	MinCount       int
+	MaxCount       int
+	DustValue      int64
+	MaxDust        int
+	MinLargest     int64
+	MinSmallest    int64
+	MaxUnconfirmed int
+}
+
+// utxoCheck summarises the UTXOs of the TSS BTC address and alarms on every
+// Tss.Utxo threshold it crosses.
+func (m *Monitor) utxoCheck() {
+	tss := m.Cfg.Tss
+	if tss == nil || tss.Utxo == nil || tss.BtcAddress == "" {
+		return
+	}
+	thresholds, err := parseUtxoThresholds(tss.Utxo)
+	if err != nil {
+		m.Log.Error("UTXO check, parse thresholds failed", "err", err)
+		return
+	}
+
+	esplora := tssEsplora(tss)
+	if esplora == nil {
+		esplora = m.Cfg.Tk.Esplora
+	}
+	client, err := newEsploraClient(esplora)
+	if err != nil {
+		m.Log.Error("UTXO check, build esplora client failed", "err", err)
+		return
+	}
+	address, err := btcutil.DecodeAddress(tss.BtcAddress, client.NetParams())
+	if err != nil {
+		m.Log.Error("UTXO check, decode address failed", "addr", tss.BtcAddress, "err", err)
+		return
+	}
+	utxos, err := client.ListUTXOs(address)
+	if err != nil {
+		m.Log.Error("UTXO check, list utxos failed", "addr", tss.BtcAddress, "err", err)
+		return
+	}
+
+	summary := summarizeUtxos(utxos, thresholds.DustValue)
+	m.Log.Info("UTXO check", "addr", tss.BtcAddress, "count", summary.Count, "dust", summary.Dust,
+		"unconfirmed", summary.Unconfirmed, "largest", summary.Largest, "smallest", summary.Smallest, "total", summary.Total)
+	for _, reason := range evaluateUtxoHealth(summary, thresholds) {
+		util.Alarm(context.Background(), fmt.Sprintf("BTC vault utxo %s,addr=%s", reason, tss.BtcAddress))
+	}
+}
+
+func summarizeUtxos(utxos mempool.UTXOs, dustValue int64) utxoSummary {
+	var summary utxoSummary
+	for i, utxo := range utxos {
+		summary.Count++
+		summary.Total += utxo.Value
+		if utxo.Value < dustValue {
+			summary.Dust++
+		}
+		if !utxo.Status.Confirmed {
+			summary.Unconfirmed++
+		}
+		if i == 0 || utxo.Value > summary.Largest {
+			summary.Largest = utxo.Value
+		}
+		if i == 0 || utxo.Value < summary.Smallest {
+			summary.Smallest = utxo.Value
+		}
+	}
+	return summary
+}
+
+// evaluateUtxoHealth returns one reason per crossed threshold. Largest and
+// smallest are only judged when there is at least one UTXO.
+func evaluateUtxoHealth(summary utxoSummary, th utxoThresholds) []string {
+	var reasons []string
+	if th.MinCount > 0 && summary.Count < th.MinCount {
+		reasons = append(reasons, fmt.Sprintf("count Less than %d, count=%d", th.MinCount, summary.Count))
+	}
+	if th.MaxCount > 0 && summary.Count > th.MaxCount {
+		reasons = append(reasons, fmt.Sprintf("count More than %d, count=%d", th.MaxCount, summary.Count))
+	}
+	if th.MaxDust > 0 && summary.Dust > th.MaxDust {
+		reasons = append(reasons, fmt.Sprintf("dust More than %d, dust=%d below %s BTC",
+			th.MaxDust, summary.Dust, btcAmount(th.DustValue)))
+	}
+	if th.MaxUnconfirmed > 0 && summary.Unconfirmed > th.MaxUnconfirmed {
+		reasons = append(reasons, fmt.Sprintf("unconfirmed More than %d, unconfirmed=%d",
+			th.MaxUnconfirmed, summary.Unconfirmed))
+	}
+	if summary.Count == 0 {
+		return reasons
+	}
+	if th.MinLargest > 0 && summary.Largest < th.MinLargest {
+		reasons = append(reasons, fmt.Sprintf("largest Less than %s BTC, largest=%s",
+			btcAmount(th.MinLargest), btcAmount(summary.Largest)))
+	}
+	if th.MinSmallest > 0 && summary.Smallest < th.MinSmallest {
+		reasons = append(reasons, fmt.Sprintf("smallest Less than %s BTC, smallest=%s",
+			btcAmount(th.MinSmallest), btcAmount(summary.Smallest)))
+	}
+	return reasons
+}
+
+func parseUtxoThresholds(cfg *config.UtxoHealth) (utxoThresholds, error) {
+	th := utxoThresholds{
+		MinCount:       cfg.MinCount,
+		MaxCount:       cfg.MaxCount,
+		DustValue:      defaultDustValue,
+		MaxDust:        cfg.MaxDust,
+		MaxUnconfirmed: cfg.MaxUnconfirmed,
+	}
+	amounts := []struct {
+		name  string
+		value string
+		dst   *int64
+	}{
+		{name: "dustValue", value: cfg.DustValue, dst: &th.DustValue},
+		{name: "minLargest", value: cfg.MinLargest, dst: &th.MinLargest},
+		{name: "minSmallest", value: cfg.MinSmallest, dst: &th.MinSmallest},
+	}
+	for _, field := range amounts {
+		if field.value == "" {
+			continue
+		}
+		sats, ok := config.ParseNativeWaterLine(field.value, 8)
+		if !ok {
+			return utxoThresholds{}, errors.Errorf("utxo %s %q Not Number", field.name, field.value)
+		}
+		*field.dst = sats.Int64()
+	}
+	return th, nil
+}
@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/mempool"
+)
+
+func newUtxo(value int64, confirmed bool) mempool.UTXO {
+	utxo := mempool.UTXO{Value: value}
+	utxo.Status.Confirmed = confirmed
+	return utxo
+}
+
+func TestSummarizeUtxos(t *testing.T) {
+	utxos := mempool.UTXOs{
+		newUtxo(500, true),
+		newUtxo(250000000, true),
+		newUtxo(3000000, false),
+		newUtxo(9999, true),
+	}
+	got := summarizeUtxos(utxos, defaultDustValue)
+	want := utxoSummary{Count: 4, Dust: 2, Unconfirmed: 1, Largest: 250000000, Smallest: 500, Total: 253010499}
+	if got != want {
+		t.Fatalf("summarizeUtxos = %+v, want %+v", got, want)
+	}
+
+	if got := summarizeUtxos(nil, defaultDustValue); got != (utxoSummary{}) {
+		t.Fatalf("summarizeUtxos(nil) = %+v, want zero", got)
+	}
+}
+
+func TestEvaluateUtxoHealth(t *testing.T) {
+	th := utxoThresholds{
+		MinCount:       3,
+		MaxCount:       100,
+		DustValue:      defaultDustValue,
+		MaxDust:        5,
+		MinLargest:     100000000,
+		MinSmallest:    1000,
+		MaxUnconfirmed: 2,
+	}
+	tests := []struct {
+		name    string
+		summary utxoSummary
+		want    []string
+	}{
+		{
+			name:    "healthy",
+			summary: utxoSummary{Count: 10, Dust: 1, Unconfirmed: 1, Largest: 200000000, Smallest: 5000},
+		},
+		{
+			name:    "empty vault only reports count",
+			summary: utxoSummary{},
+			want:    []string{"count Less than 3"},
+		},
+		{
+			name:    "too many small utxos",
+			summary: utxoSummary{Count: 120, Dust: 30, Unconfirmed: 4, Largest: 50000000, Smallest: 546},
+			want: []string{"count More than 100", "dust More than 5", "unconfirmed More than 2",
+				"largest Less than 1 BTC", "smallest Less than 0.00001 BTC"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateUtxoHealth(tt.summary, th)
+			if len(got) != len(tt.want) {
+				t.Fatalf("evaluateUtxoHealth = %q, want %d reasons", got, len(tt.want))
+			}
+			for i, prefix := range tt.want {
+				if !strings.HasPrefix(got[i], prefix) {
+					t.Fatalf("reason %d = %q, want prefix %q", i, got[i], prefix)
+				}
+			}
+		})
+	}
+
+	if got := evaluateUtxoHealth(utxoSummary{Count: 1000, Dust: 1000}, utxoThresholds{}); len(got) != 0 {
+		t.Fatalf("zero thresholds must disable every alarm, got %q", got)
+	}
+}
+
+func TestParseUtxoThresholds(t *testing.T) {
+	th, err := parseUtxoThresholds(&config.UtxoHealth{MinCount: 3, MinLargest: "0.5", MinSmallest: "0.00001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.DustValue != defaultDustValue || th.MinLargest != 50000000 || th.MinSmallest != 1000 || th.MinCount != 3 {
+		t.Fatalf("parseUtxoThresholds = %+v", th)
+	}
+
+	if _, err := parseUtxoThresholds(&config.UtxoHealth{DustValue: "abc"}); err == nil {
+		t.Fatal("expected error for non-numeric dustValue")
+	}
+}
@@ -0,0 +1,110 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mapprotocol/monitor/pkg/util"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultCacheTTL   = time.Minute
+	DefaultStaleAfter = 15 * time.Minute
+)
+
+type entry struct {
+	quote     Quote
+	err       error     // of the last lookup
+	attempted time.Time // when the last lookup started
+	fetching  bool
+	stale     bool
+}
+
+// Cache is a Pricer that asks its Provider at most once per ttl for each
+// symbol, whether the lookup succeeds or not, and never while holding its
+// lock, so a slow feed does not hold up callers served from the cache. When
+// the provider fails it keeps serving the last quote until that quote is
+// older than staleAfter; from then on Price fails and one alarm is raised
+// until a fresh quote arrives.
+type Cache struct {
+	provider   Provider
+	ttl        time.Duration
+	staleAfter time.Duration
+	now        func() time.Time
+	alarm      func(msg string)
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func NewCache(provider Provider, ttl, staleAfter time.Duration) *Cache {
+	return &Cache{
+		provider:   provider,
+		ttl:        ttl,
+		staleAfter: staleAfter,
+		now:        time.Now,
+		alarm:      func(msg string) { util.Alarm(context.Background(), msg) },
+		entries:    make(map[string]*entry),
+	}
+}
+
+func (c *Cache) Price(symbol string) (decimal.Decimal, error) {
+	symbol = strings.ToUpper(symbol)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	e := c.entries[symbol]
+	if e == nil {
+		e = &entry{}
+		c.entries[symbol] = e
+	}
+	if !e.fetching && (e.attempted.IsZero() || now.Sub(e.attempted) >= c.ttl) {
+		e.attempted, e.fetching = now, true
+		c.mu.Unlock()
+		quote, err := c.provider.Quote(symbol)
+		c.mu.Lock()
+		e.fetching = false
+		if err == nil {
+			e.quote = quote
+			if c.isStale(e, now) {
+				err = fmt.Errorf("quote from %s", quote.Time.Format(time.RFC3339))
+			}
+		}
+		e.err = err
+	}
+	if !c.isStale(e, now) {
+		e.stale = false
+		return e.quote.Price, nil
+	}
+	if e.fetching && e.quote.Time.IsZero() {
+		return decimal.Zero, fmt.Errorf("price of %s is being fetched", symbol)
+	}
+
+	err := e.err
+	if err == nil {
+		// the last quote aged out before the next lookup is due
+		err = fmt.Errorf("quote from %s", lastQuote(e))
+	}
+	if !e.stale {
+		e.stale = true
+		c.alarm(fmt.Sprintf("Price feed stale, symbol=%s, staleAfter=%s, last=%s, err=%v",
+			symbol, c.staleAfter, lastQuote(e), err))
+	}
+	return decimal.Zero, fmt.Errorf("price of %s is stale: %w", symbol, err)
+}
+
+func (c *Cache) isStale(e *entry, now time.Time) bool {
+	return e.quote.Time.IsZero() || now.Sub(e.quote.Time) > c.staleAfter
+}
+
+func lastQuote(e *entry) string {
+	if e.quote.Time.IsZero() {
+		return "never"
+	}
+	return e.quote.Time.Format(time.RFC3339)
+}
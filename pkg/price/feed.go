@@ -0,0 +1,104 @@
+package price
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultTimeout = 10 * time.Second
+	DefaultField   = "price"
+)
+
+// Feed reads quotes from a JSON HTTP endpoint. url may contain "{symbol}",
+// which is replaced by the upper-case token symbol. The price is read from
+// field and the quote time, in unix seconds, from timeField; both accept a
+// dotted path such as "data.price". Without timeField the quote is dated
+// when it was fetched.
+type Feed struct {
+	url       string
+	field     string
+	timeField string
+	client    *http.Client
+}
+
+func NewFeed(url, field, timeField string, timeout time.Duration) *Feed {
+	if field == "" {
+		field = DefaultField
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Feed{
+		url:       url,
+		field:     field,
+		timeField: timeField,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+func (f *Feed) Quote(symbol string) (Quote, error) {
+	url := strings.ReplaceAll(f.url, "{symbol}", strings.ToUpper(symbol))
+	resp, err := f.client.Get(url)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Quote{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Quote{}, fmt.Errorf("price feed %s: status %d, body %s", url, resp.StatusCode, body)
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err = dec.Decode(&doc); err != nil {
+		return Quote{}, fmt.Errorf("price feed %s: %w", url, err)
+	}
+
+	price, err := decimalField(doc, f.field)
+	if err != nil {
+		return Quote{}, fmt.Errorf("price feed %s: %w", url, err)
+	}
+	quote := Quote{Price: price, Time: time.Now()}
+	if f.timeField != "" {
+		ts, err := decimalField(doc, f.timeField)
+		if err != nil {
+			return Quote{}, fmt.Errorf("price feed %s: %w", url, err)
+		}
+		quote.Time = time.Unix(ts.IntPart(), 0)
+	}
+	return quote, nil
+}
+
+// decimalField reads the number or numeric string at the dotted path.
+func decimalField(doc interface{}, path string) (decimal.Decimal, error) {
+	value := doc
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return decimal.Zero, fmt.Errorf("field %q not found", path)
+		}
+		if value, ok = obj[key]; !ok {
+			return decimal.Zero, fmt.Errorf("field %q not found", path)
+		}
+	}
+	switch v := value.(type) {
+	case json.Number:
+		return decimal.NewFromString(v.String())
+	case string:
+		return decimal.NewFromString(v)
+	default:
+		return decimal.Zero, fmt.Errorf("field %q is not a number", path)
+	}
+}
@@ -0,0 +1,127 @@
+// Package price values native tokens in USD for balance alarms and
+// fiat-denominated waterLines.
+package price
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/shopspring/decimal"
+)
+
+// Quote is the USD price of one whole token and the time it was quoted.
+type Quote struct {
+	Price decimal.Decimal
+	Time  time.Time
+}
+
+// Provider returns the latest quote for a token symbol such as "ETH".
+type Provider interface {
+	Quote(symbol string) (Quote, error)
+}
+
+// Pricer returns a usable USD price for a token symbol.
+type Pricer interface {
+	Price(symbol string) (decimal.Decimal, error)
+}
+
+var (
+	mu       sync.RWMutex
+	defaults Pricer
+)
+
+// SetDefault installs the Pricer used by USD, Describe and WaterLine; nil
+// turns USD valuation off.
+func SetDefault(p Pricer) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaults = p
+}
+
+// Default returns the Pricer installed by SetDefault, or nil.
+func Default() Pricer {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaults
+}
+
+// FromConfig builds the cached feed described by cfg, or nil when cfg is nil.
+func FromConfig(cfg *config.Price) Pricer {
+	if cfg == nil {
+		return nil
+	}
+	feed := NewFeed(cfg.Url, cfg.Field, cfg.TimeField, seconds(cfg.Timeout, DefaultTimeout))
+	return NewCache(feed, seconds(cfg.CacheTTL, DefaultCacheTTL), seconds(cfg.StaleAfter, DefaultStaleAfter))
+}
+
+func seconds(value int64, def time.Duration) time.Duration {
+	if value <= 0 {
+		return def
+	}
+	return time.Duration(value) * time.Second
+}
+
+// USD values a in dollars using the default Pricer.
+func USD(symbol string, a amount.Amount) (decimal.Decimal, bool) {
+	p := Default()
+	if p == nil || symbol == "" {
+		return decimal.Zero, false
+	}
+	usd, err := p.Price(symbol)
+	if err != nil {
+		return decimal.Zero, false
+	}
+	return a.Decimal().Mul(usd), true
+}
+
+// Describe formats the USD value of a for an alarm or log line, e.g.
+// " usd=1234.56", or returns "" when no price is available.
+func Describe(symbol string, a amount.Amount) string {
+	usd, ok := USD(symbol, a)
+	if !ok {
+		return ""
+	}
+	return " usd=" + usd.StringFixed(2)
+}
+
+// WaterLine parses a waterLine in native units of a token with decimals, or,
+// when written as "$500", converts the dollar amount at the current price
+// of symbol. The result is in the token's smallest unit.
+func WaterLine(value, symbol string, decimals int32) (*big.Int, error) {
+	if !config.IsFiatWaterLine(value) {
+		wl, ok := config.ParseNativeWaterLine(value, decimals)
+		if !ok {
+			return nil, fmt.Errorf("waterLine %q Not Number", value)
+		}
+		return wl, nil
+	}
+	usd, ok := config.ParseFiatWaterLine(value)
+	if !ok {
+		return nil, fmt.Errorf("waterLine %q Not Number", value)
+	}
+	p := Default()
+	if p == nil {
+		return nil, fmt.Errorf("waterLine %q needs a price feed", value)
+	}
+	if symbol == "" {
+		return nil, fmt.Errorf("waterLine %q needs a native token symbol", value)
+	}
+	unit, err := p.Price(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !unit.IsPositive() {
+		return nil, fmt.Errorf("price of %s is %s", strings.ToUpper(symbol), unit)
+	}
+	// Round up so a balance worth slightly less than the threshold still alarms.
+	q, r := usd.Shift(decimals).QuoRem(unit, 0)
+	if r.IsPositive() {
+		q = q.Add(decimal.NewFromInt(1))
+	}
+	return q.BigInt(), nil
+}
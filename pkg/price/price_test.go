@@ -0,0 +1,196 @@
+package price
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mapprotocol/monitor/pkg/amount"
+	"github.com/shopspring/decimal"
+)
+
+func TestFeed_Quote(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("symbol") {
+		case "ETH":
+			fmt.Fprint(w, `{"data":{"price":"3200.25","ts":1700000000}}`)
+		case "TRX":
+			fmt.Fprint(w, `{"data":{"price":0.125,"ts":1700000001}}`)
+		default:
+			http.Error(w, "unknown symbol", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	feed := NewFeed(srv.URL+"?symbol={symbol}", "data.price", "data.ts", time.Second)
+	quote, err := feed.Quote("eth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Price.String() != "3200.25" || quote.Time.Unix() != 1700000000 {
+		t.Fatalf("ETH quote = %s at %d", quote.Price, quote.Time.Unix())
+	}
+	if quote, err = feed.Quote("TRX"); err != nil || quote.Price.String() != "0.125" {
+		t.Fatalf("TRX quote = %s, err %v", quote.Price, err)
+	}
+	if _, err = feed.Quote("DOGE"); err == nil {
+		t.Fatal("expected an error for a 404")
+	}
+	if _, err = NewFeed(srv.URL+"?symbol={symbol}", "price", "", time.Second).Quote("ETH"); err == nil {
+		t.Fatal("expected an error for a missing field")
+	}
+}
+
+type stubProvider struct {
+	quote Quote
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Quote(string) (Quote, error) {
+	p.calls++
+	return p.quote, p.err
+}
+
+func TestCache_TTLAndStaleAlarm(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	now := start
+	provider := &stubProvider{quote: Quote{Price: decimal.NewFromInt(2), Time: start}}
+	var alarms []string
+
+	c := NewCache(provider, time.Minute, 10*time.Minute)
+	c.now = func() time.Time { return now }
+	c.alarm = func(msg string) { alarms = append(alarms, msg) }
+
+	if p, err := c.Price("eth"); err != nil || !p.Equal(decimal.NewFromInt(2)) {
+		t.Fatalf("Price = %s, %v", p, err)
+	}
+	now = start.Add(30 * time.Second)
+	if _, err := c.Price("ETH"); err != nil || provider.calls != 1 {
+		t.Fatalf("expected a cached price, calls=%d err=%v", provider.calls, err)
+	}
+
+	// The feed fails: the last quote is served until it is staleAfter old.
+	provider.err = errors.New("feed down")
+	now = start.Add(5 * time.Minute)
+	if p, err := c.Price("ETH"); err != nil || !p.Equal(decimal.NewFromInt(2)) {
+		t.Fatalf("expected the last quote while fresh, got %s, %v", p, err)
+	}
+	if len(alarms) != 0 {
+		t.Fatalf("unexpected alarms %v", alarms)
+	}
+
+	now = start.Add(11 * time.Minute)
+	if _, err := c.Price("ETH"); err == nil {
+		t.Fatal("expected a stale error")
+	}
+	if _, err := c.Price("ETH"); err == nil {
+		t.Fatal("expected a stale error")
+	}
+	if len(alarms) != 1 {
+		t.Fatalf("expected one stale alarm, got %v", alarms)
+	}
+	if provider.calls != 3 {
+		t.Fatalf("a failed lookup was retried within the ttl: calls=%d", provider.calls)
+	}
+
+	// A feed that answers with an old quote is stale too.
+	provider.err = nil
+	now = now.Add(time.Minute)
+	if _, err := c.Price("ETH"); err == nil || len(alarms) != 1 {
+		t.Fatalf("expected a stale quote to fail without a new alarm, err=%v alarms=%v", err, alarms)
+	}
+
+	now = now.Add(time.Minute)
+	provider.quote = Quote{Price: decimal.NewFromInt(3), Time: now}
+	if p, err := c.Price("ETH"); err != nil || !p.Equal(decimal.NewFromInt(3)) {
+		t.Fatalf("expected recovery, got %s, %v", p, err)
+	}
+	provider.err = errors.New("feed down")
+	now = now.Add(20 * time.Minute)
+	if _, err := c.Price("ETH"); err == nil || len(alarms) != 2 {
+		t.Fatalf("expected a second alarm after recovery, err=%v alarms=%v", err, alarms)
+	}
+}
+
+// blockingProvider answers once release is closed.
+type blockingProvider struct {
+	started, release chan struct{}
+}
+
+func (p *blockingProvider) Quote(string) (Quote, error) {
+	close(p.started)
+	<-p.release
+	return Quote{Price: decimal.NewFromInt(1), Time: time.Now()}, nil
+}
+
+func TestCache_LookupDoesNotHoldLock(t *testing.T) {
+	provider := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	c := NewCache(provider, time.Minute, 10*time.Minute)
+	c.alarm = func(string) {}
+
+	done := make(chan error)
+	go func() {
+		_, err := c.Price("ETH")
+		done <- err
+	}()
+	<-provider.started
+
+	// a caller arriving during the lookup is answered at once, not queued
+	if _, err := c.Price("ETH"); err == nil {
+		t.Fatal("expected an error before the first quote arrived")
+	}
+	close(provider.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if p, err := c.Price("ETH"); err != nil || !p.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("Price = %s, %v", p, err)
+	}
+}
+
+type fixedPricer map[string]decimal.Decimal
+
+func (p fixedPricer) Price(symbol string) (decimal.Decimal, error) {
+	if v, ok := p[symbol]; ok {
+		return v, nil
+	}
+	return decimal.Zero, errors.New("no price")
+}
+
+func TestWaterLine(t *testing.T) {
+	SetDefault(nil)
+	defer SetDefault(nil)
+
+	if wl, err := WaterLine("5.5", "TRX", 6); err != nil || wl.String() != "5500000" {
+		t.Fatalf("native waterLine = %s, %v", wl, err)
+	}
+	if _, err := WaterLine("$500", "TRX", 6); err == nil {
+		t.Fatal("expected an error without a price feed")
+	}
+
+	SetDefault(fixedPricer{"TRX": decimal.RequireFromString("0.125"), "ETH": decimal.RequireFromString("3000")})
+	if wl, err := WaterLine("$500", "TRX", 6); err != nil || wl.String() != "4000000000" {
+		t.Fatalf("$500 of TRX = %s, %v", wl, err)
+	}
+	// 1000/3000 ETH rounds up to the next wei.
+	if wl, err := WaterLine("$1000", "ETH", 18); err != nil || wl.String() != "333333333333333334" {
+		t.Fatalf("$1000 of ETH = %s, %v", wl, err)
+	}
+	if _, err := WaterLine("$500", "", 18); err == nil {
+		t.Fatal("expected an error without a symbol")
+	}
+	if _, err := WaterLine("$abc", "ETH", 18); err == nil {
+		t.Fatal("expected an error for a malformed dollar amount")
+	}
+
+	if got := Describe("ETH", amount.FromInt64(15, 1)); got != " usd=4500.00" {
+		t.Fatalf("Describe = %q", got)
+	}
+	if got := Describe("SOL", amount.FromInt64(1, 0)); got != "" {
+		t.Fatalf("Describe without a price = %q", got)
+	}
+}
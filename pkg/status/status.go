@@ -0,0 +1,115 @@
+// Package status serves the latest state published by the monitors as JSON
+// over HTTP, for dashboards and on-call tooling.
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Section is one published value and when it was last set.
+type Section struct {
+	Updated time.Time   `json:"updated"`
+	Value   interface{} `json:"value"`
+}
+
+// Registry holds the latest value of every named section.
+type Registry struct {
+	mu       sync.RWMutex
+	sections map[string]Section
+	now      func() time.Time
+}
+
+func NewRegistry() *Registry {
+	return &Registry{sections: make(map[string]Section), now: time.Now}
+}
+
+var std = NewRegistry()
+
+// Default returns the registry Publish writes to and Serve serves.
+func Default() *Registry {
+	return std
+}
+
+// Publish sets section name of the default registry to v.
+func Publish(name string, v interface{}) {
+	std.Set(name, v)
+}
+
+// Set replaces section name with v. v must marshal to JSON and must not be
+// modified afterwards.
+func (r *Registry) Set(name string, v interface{}) {
+	r.mu.Lock()
+	r.sections[name] = Section{Updated: r.now(), Value: v}
+	r.mu.Unlock()
+}
+
+// Get returns section name.
+func (r *Registry) Get(name string) (Section, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.sections[name]
+	return s, ok
+}
+
+// Names lists the published sections in order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.sections))
+	for name := range r.sections {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// ServeHTTP answers GET / with every section and GET /<name> with one.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body interface{}
+	name := strings.Trim(req.URL.Path, "/")
+	if name == "" {
+		all := make(map[string]Section)
+		for _, n := range r.Names() {
+			all[n], _ = r.Get(n)
+		}
+		body = all
+	} else {
+		s, ok := r.Get(name)
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		body = s
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// Serve serves the default registry on addr under /status until ctx is
+// done.
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/status/", http.StripPrefix("/status", std))
+	mux.Handle("/status", http.StripPrefix("/status", std))
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
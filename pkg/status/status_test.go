@@ -0,0 +1,39 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.now = func() time.Time { return time.Unix(1700000000, 0) }
+	r.Set("mesh", map[string]int{"healthy": 3})
+	r.Set("gas", []string{"eth"})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var all map[string]Section
+	if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil || len(all) != 2 {
+		t.Fatalf("GET / = %s, %v", rec.Body, err)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/mesh", nil))
+	var one struct {
+		Updated time.Time
+		Value   map[string]int
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &one); err != nil || one.Value["healthy"] != 3 || one.Updated.Unix() != 1700000000 {
+		t.Fatalf("GET /mesh = %s, %v", rec.Body, err)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("GET /missing = %d", rec.Code)
+	}
+}
@@ -0,0 +1,115 @@
+// Package tokenmeta discovers the decimals and symbol of ERC-20 style tokens
+// on first use and caches them per token address.
+package tokenmeta
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/ChainSafe/log15"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/internal/mapprotocol"
+)
+
+// Meta is what the chain reports for a token.
+type Meta struct {
+	Decimals int32
+	Symbol   string
+}
+
+// Caller makes a read-only call of data against token and returns the raw
+// ABI-encoded output.
+type Caller func(token string, data []byte) ([]byte, error)
+
+// Cache resolves token metadata through a Caller.
+type Cache struct {
+	call Caller
+	log  log15.Logger
+
+	mu    sync.Mutex
+	metas map[string]Meta
+}
+
+func NewCache(call Caller, log log15.Logger) *Cache {
+	return &Cache{call: call, log: log, metas: make(map[string]Meta)}
+}
+
+// Resolve returns the metadata of tk, asking the chain the first time its
+// address is seen. The chain wins over the configured wei and symbol, with a
+// warning when they disagree. When the chain can not be asked the
+// configured values are used (18 decimals when wei is unset) and the lookup
+// is retried on the next call.
+func (c *Cache) Resolve(tk config.EthToken) Meta {
+	key := strings.ToLower(tk.Addr)
+	c.mu.Lock()
+	meta, ok := c.metas[key]
+	c.mu.Unlock()
+	if ok {
+		return meta
+	}
+
+	configured := Meta{Decimals: tk.Decimals(), Symbol: tk.Symbol}
+	decimals, err := c.decimals(tk.Addr)
+	if err != nil {
+		c.log.Warn("Token decimals() failed, use configured wei", "token", tk.Name, "addr", tk.Addr,
+			"wei", configured.Decimals, "err", err)
+		return configured
+	}
+	meta = Meta{Decimals: decimals, Symbol: tk.Symbol}
+	if symbol, err := c.symbol(tk.Addr); err != nil {
+		c.log.Warn("Token symbol() failed", "token", tk.Name, "addr", tk.Addr, "err", err)
+	} else {
+		meta.Symbol = symbol
+	}
+
+	if tk.Wei != 0 && int32(tk.Wei) != meta.Decimals {
+		c.log.Warn("Configured token wei disagrees with chain, use chain decimals", "token", tk.Name,
+			"addr", tk.Addr, "wei", tk.Wei, "decimals", meta.Decimals)
+	}
+	if tk.Symbol != "" && meta.Symbol != "" && !strings.EqualFold(tk.Symbol, meta.Symbol) {
+		c.log.Warn("Configured token symbol disagrees with chain", "token", tk.Name,
+			"addr", tk.Addr, "symbol", tk.Symbol, "chain", meta.Symbol)
+	}
+
+	c.mu.Lock()
+	c.metas[key] = meta
+	c.mu.Unlock()
+	return meta
+}
+
+func (c *Cache) decimals(token string) (int32, error) {
+	input, err := mapprotocol.TokenAbi.PackInput("decimals")
+	if err != nil {
+		return 0, err
+	}
+	output, err := c.call(token, input)
+	if err != nil {
+		return 0, err
+	}
+	var decimals uint8
+	if err = mapprotocol.TokenAbi.UnpackOutput("decimals", &decimals, output); err != nil {
+		return 0, err
+	}
+	return int32(decimals), nil
+}
+
+func (c *Cache) symbol(token string) (string, error) {
+	input, err := mapprotocol.TokenAbi.PackInput("symbol")
+	if err != nil {
+		return "", err
+	}
+	output, err := c.call(token, input)
+	if err != nil {
+		return "", err
+	}
+	var symbol string
+	if err = mapprotocol.TokenAbi.UnpackOutput("symbol", &symbol, output); err == nil {
+		return symbol, nil
+	}
+	// Some older tokens (e.g. MKR) return the symbol as bytes32.
+	if len(output) == 32 {
+		return string(bytes.TrimRight(output, "\x00")), nil
+	}
+	return "", err
+}
@@ -0,0 +1,87 @@
+package tokenmeta
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ChainSafe/log15"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/mapprotocol/monitor/internal/config"
+	"github.com/mapprotocol/monitor/internal/mapprotocol"
+)
+
+type fakeToken struct {
+	decimals []byte
+	symbol   []byte
+	err      error
+	calls    int
+}
+
+func (f *fakeToken) call(_ string, data []byte) ([]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	decimals, _ := mapprotocol.TokenAbi.PackInput("decimals")
+	if bytes.Equal(data, decimals) {
+		return f.decimals, nil
+	}
+	return f.symbol, nil
+}
+
+func pack(t *testing.T, typ string, value interface{}) []byte {
+	t.Helper()
+	ty, err := abi.NewType(typ, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := abi.Arguments{{Type: ty}}.Pack(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestResolve_DiscoversAndCaches(t *testing.T) {
+	f := &fakeToken{decimals: pack(t, "uint8", uint8(6)), symbol: pack(t, "string", "USDT")}
+	c := NewCache(f.call, log15.New())
+
+	// wei and symbol are wrong in config: the chain wins
+	tk := config.EthToken{Name: "usdt", Addr: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Wei: 18, Symbol: "USDC"}
+	meta := c.Resolve(tk)
+	if meta.Decimals != 6 || meta.Symbol != "USDT" {
+		t.Fatalf("Resolve = %+v, want 6 USDT", meta)
+	}
+	calls := f.calls
+	tk.Addr = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	if meta = c.Resolve(tk); meta.Decimals != 6 || f.calls != calls {
+		t.Fatalf("expected a cached result, got %+v after %d calls", meta, f.calls-calls)
+	}
+}
+
+func TestResolve_Bytes32Symbol(t *testing.T) {
+	symbol := make([]byte, 32)
+	copy(symbol, "MKR")
+	f := &fakeToken{decimals: pack(t, "uint8", uint8(18)), symbol: symbol}
+	meta := NewCache(f.call, log15.New()).Resolve(config.EthToken{Name: "mkr", Addr: "0x1"})
+	if meta.Decimals != 18 || meta.Symbol != "MKR" {
+		t.Fatalf("Resolve = %+v, want 18 MKR", meta)
+	}
+}
+
+func TestResolve_FallsBackToConfigAndRetries(t *testing.T) {
+	f := &fakeToken{err: errors.New("rpc down")}
+	c := NewCache(f.call, log15.New())
+	tk := config.EthToken{Name: "usdc", Addr: "0x2", Wei: 6, Symbol: "USDC"}
+
+	if meta := c.Resolve(tk); meta.Decimals != 6 || meta.Symbol != "USDC" {
+		t.Fatalf("Resolve = %+v, want configured 6 USDC", meta)
+	}
+
+	f.err = nil
+	f.decimals, f.symbol = pack(t, "uint8", uint8(8)), pack(t, "string", "USDC")
+	if meta := c.Resolve(tk); meta.Decimals != 8 {
+		t.Fatalf("expected a retry after failure, got %+v", meta)
+	}
+}
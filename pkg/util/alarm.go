@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/lbtsm/mapo-lib/alarm"
@@ -11,12 +12,30 @@ import (
 
 var Env = ""
 
+// Severity ranks an alarm. Alarm sends at SeverityWarning; the other levels
+// prefix the message with their name so they stand out in the alarm channel.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
 func init() {
 	Env = os.Getenv("compass")
 	alarm.Init(Env, os.Getenv("hooks"))
 }
 
 func Alarm(ctx context.Context, msg string) {
+	AlarmLevel(ctx, SeverityWarning, msg)
+}
+
+// AlarmLevel sends msg at severity.
+func AlarmLevel(ctx context.Context, severity Severity, msg string) {
+	if severity != SeverityWarning && severity != "" {
+		msg = "[" + strings.ToUpper(string(severity)) + "] " + msg
+	}
 	fmt.Printf("%s [ALARM] %s\n", time.Now().Format("2006-01-02T15:04:05"), msg)
 	_ = alarm.Send(ctx, msg)
 }